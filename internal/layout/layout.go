@@ -0,0 +1,268 @@
+// Package layout builds a per-file model of how an HCL file is laid out: line offsets,
+// token-aware blank/comment classification and the ordered items of each body with the
+// comment groups attached to them.
+//
+// Models are cached by file name and content, so all rules in one check pass share a
+// single build per file instead of re-scanning the source on every rule.
+package layout
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// LineKind classifies a physical line of a file.
+type LineKind int
+
+const (
+	Code    LineKind = iota // contains configuration tokens
+	Blank                   // only whitespace, outside of any literal
+	Comment                 // only a comment (or part of a multi-line comment)
+	Literal                 // starts inside a heredoc or multi-line string; must be preserved as-is
+)
+
+// ItemKind tells attributes and nested blocks apart.
+type ItemKind int
+
+const (
+	Attribute ItemKind = iota
+	Block
+)
+
+// Item is an attribute or a nested block of a body.
+type Item struct {
+	Kind  ItemKind
+	Name  string // attribute name or block type
+	Attr  *hclsyntax.Attribute
+	Block *hclsyntax.Block
+	// Range spans from the attribute name (or block type) to the end of the expression (or closing brace).
+	Range hcl.Range
+	// CommentLine is the first line of the comment group directly above the item, with no blank
+	// line in between. It equals Range.Start.Line when the item has no leading comments.
+	CommentLine int
+}
+
+// StartLine returns the line of the attribute name or block type.
+func (it Item) StartLine() int { return it.Range.Start.Line }
+
+// EndLine returns the line on which the item ends.
+func (it Item) EndLine() int { return it.Range.End.Line }
+
+// File is the layout model of a single file. Body is the body the runner parsed when the model
+// was built, and Items only works for bodies reachable from it.
+type File struct {
+	Filename string
+	Src      []byte
+	Body     *hclsyntax.Body // nil if the file is not native HCL syntax
+
+	starts []int
+	kinds  []LineKind
+
+	mu    sync.Mutex
+	items map[*hclsyntax.Body][]Item
+}
+
+var cache = struct {
+	sync.Mutex
+	files map[string]*File
+}{files: map[string]*File{}}
+
+// Get returns the model for the named file, building it on first use from the file the runner
+// returned. A cached model is reused for as long as the file content is unchanged, so rules must
+// walk the model's Body rather than the file's own.
+func Get(filename string, file *hcl.File) *File {
+	cache.Lock()
+	defer cache.Unlock()
+	if f, ok := cache.files[filename]; ok && bytes.Equal(f.Src, file.Bytes) {
+		return f
+	}
+	f := New(filename, file)
+	cache.files[filename] = f
+	return f
+}
+
+// New builds an uncached model of file. Body is nil unless the file is native HCL syntax.
+func New(filename string, file *hcl.File) *File {
+	f := &File{Filename: filename, Src: file.Bytes, items: map[*hclsyntax.Body][]Item{}}
+	f.Body, _ = file.Body.(*hclsyntax.Body)
+	f.classifyLines()
+	return f
+}
+
+// classifyLines lexes the source and classifies each line. Lines whose start lies inside a
+// heredoc or a quoted template are Literal, never Blank.
+func (f *File) classifyLines() {
+	src := f.Src
+	f.starts = make([]int, 1, len(src)/16+2)
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			f.starts = append(f.starts, i+1)
+		}
+	}
+
+	// Collect the outermost literal spans [contentStart, contentEnd] and comment tokens.
+	type span struct{ start, end int }
+	literals := []span{}
+	comments := []span{}
+	tokens, _ := hclsyntax.LexConfig(src, f.Filename, hcl.Pos{Line: 1, Column: 1})
+	depth := 0
+	open := 0
+	for _, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOHeredoc, hclsyntax.TokenOQuote:
+			if depth == 0 {
+				open = tok.Range.End.Byte
+			}
+			depth++
+		case hclsyntax.TokenCHeredoc, hclsyntax.TokenCQuote:
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				literals = append(literals, span{open, tok.Range.Start.Byte})
+			}
+		case hclsyntax.TokenComment:
+			comments = append(comments, span{tok.Range.Start.Byte, tok.Range.End.Byte})
+		}
+	}
+	if depth > 0 {
+		// Unterminated literal: protect everything up to EOF
+		literals = append(literals, span{open, len(src)})
+	}
+
+	f.kinds = make([]LineKind, len(f.starts))
+	li, ci := 0, 0
+	for i, b := range f.starts {
+		for li < len(literals) && literals[li].end < b {
+			li++
+		}
+		for ci < len(comments) && comments[ci].end <= b {
+			ci++
+		}
+		text := strings.TrimSpace(f.LineText(i + 1))
+		switch {
+		case li < len(literals) && literals[li].start <= b:
+			f.kinds[i] = Literal
+		case ci < len(comments) && comments[ci].start < b:
+			f.kinds[i] = Comment
+		case text == "":
+			f.kinds[i] = Blank
+		case strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*"):
+			f.kinds[i] = Comment
+		default:
+			f.kinds[i] = Code
+		}
+	}
+}
+
+// LineCount returns the number of lines. Like strings.Split(src, "\n"), a file ending with a
+// newline has a final empty line.
+func (f *File) LineCount() int { return len(f.starts) }
+
+// Kind returns the classification of the 1-based line, or Code when out of range.
+func (f *File) Kind(line int) LineKind {
+	if line < 1 || line > len(f.kinds) {
+		return Code
+	}
+	return f.kinds[line-1]
+}
+
+// IsBlank reports whether the line is blank and outside of any literal.
+func (f *File) IsBlank(line int) bool { return f.Kind(line) == Blank }
+
+// IsComment reports whether the line holds only a comment.
+func (f *File) IsComment(line int) bool { return f.Kind(line) == Comment }
+
+// LineStart returns the byte offset of the 1-based line; lines past the end map to len(Src).
+func (f *File) LineStart(line int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(f.starts) {
+		return len(f.Src)
+	}
+	return f.starts[line-1]
+}
+
+// LineText returns the text of the line without its line ending.
+func (f *File) LineText(line int) string {
+	if line < 1 || line > len(f.starts) {
+		return ""
+	}
+	return strings.TrimRight(string(f.Src[f.LineStart(line):f.LineStart(line+1)]), "\r\n")
+}
+
+// LinePos returns the position of the first column of the line.
+func (f *File) LinePos(line int) hcl.Pos {
+	return hcl.Pos{Line: line, Column: 1, Byte: f.LineStart(line)}
+}
+
+// LineAnchor returns a zero-width range at the start of the line, for use as an insertion point.
+func (f *File) LineAnchor(line int) hcl.Range {
+	return hcl.Range{Filename: f.Filename, Start: f.LinePos(line), End: f.LinePos(line)}
+}
+
+// LinesRange returns the range covering whole lines [from, to), including the newline of the last line.
+func (f *File) LinesRange(from, to int) hcl.Range {
+	return hcl.Range{Filename: f.Filename, Start: f.LinePos(from), End: f.LinePos(to)}
+}
+
+// Items returns the attributes and nested blocks of body in source order. The result is
+// computed once per body; body must belong to f.Body.
+func (f *File) Items(body *hclsyntax.Body) []Item {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if items, ok := f.items[body]; ok {
+		return items
+	}
+
+	items := make([]Item, 0, len(body.Attributes)+len(body.Blocks))
+	for name, a := range body.Attributes {
+		items = append(items, Item{
+			Kind:  Attribute,
+			Name:  name,
+			Attr:  a,
+			Range: hcl.Range{Filename: f.Filename, Start: a.NameRange.Start, End: a.Expr.Range().End},
+		})
+	}
+	for _, b := range body.Blocks {
+		items = append(items, Item{
+			Kind:  Block,
+			Name:  b.Type,
+			Block: b,
+			Range: hcl.Range{Filename: f.Filename, Start: b.TypeRange.Start, End: b.CloseBraceRange.End},
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Range.Start.Byte < items[j].Range.Start.Byte })
+
+	for i := range items {
+		floor := 0
+		if i > 0 {
+			floor = items[i-1].EndLine()
+		}
+		top := items[i].StartLine()
+		for l := top - 1; l > floor && f.IsComment(l); l-- {
+			top = l
+		}
+		items[i].CommentLine = top
+	}
+
+	f.items[body] = items
+	return items
+}
+
+// ItemLines returns the byte span of the whole lines holding the item, from its leading
+// comment group (when withComments is set) through the newline that ends it.
+func (f *File) ItemLines(it Item, withComments bool) (start, end int) {
+	first := it.StartLine()
+	if withComments {
+		first = it.CommentLine
+	}
+	return f.LineStart(first), f.LineStart(it.EndLine() + 1)
+}
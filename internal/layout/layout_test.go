@@ -0,0 +1,90 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// parse returns src as the runner would hand it to a rule.
+func parse(t *testing.T, filename, src string) *hcl.File {
+	t.Helper()
+	file, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected parse error: %s", diags)
+	}
+	return file
+}
+
+func Test_LineKinds(t *testing.T) {
+	src := "# header\n" +
+		"locals {\n" +
+		"\n" +
+		"  script = <<-EOT\n" +
+		"    echo a\n" +
+		"\n" +
+		"  EOT\n" +
+		"  /* multi\n" +
+		"\n" +
+		"  */\n" +
+		"  s = \"${\n" +
+		"\n" +
+		"  1}\"\n" +
+		"}\n"
+	f := New("main.tf", parse(t, "main.tf", src))
+	want := []LineKind{Comment, Code, Blank, Code, Literal, Literal, Literal, Comment, Comment, Comment, Code, Literal, Literal, Code, Blank}
+	if f.LineCount() != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), f.LineCount())
+	}
+	for i, k := range want {
+		if got := f.Kind(i + 1); got != k {
+			t.Errorf("line %d: expected kind %d, got %d", i+1, k, got)
+		}
+	}
+}
+
+func Test_ItemsAttachComments(t *testing.T) {
+	src := "resource \"null_resource\" \"a\" {\n" +
+		"  count = 1\n" +
+		"\n" +
+		"  # about triggers\n" +
+		"  # more\n" +
+		"  triggers = {}\n" +
+		"  # about block\n" +
+		"  lifecycle {}\n" +
+		"}\n"
+	f := New("main.tf", parse(t, "main.tf", src))
+	items := f.Items(f.Body.Blocks[0].Body)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	cases := []struct {
+		name        string
+		kind        ItemKind
+		commentLine int
+	}{
+		{"count", Attribute, 2},
+		{"triggers", Attribute, 4},
+		{"lifecycle", Block, 7},
+	}
+	for i, c := range cases {
+		if items[i].Name != c.name || items[i].Kind != c.kind || items[i].CommentLine != c.commentLine {
+			t.Errorf("item %d: expected %s/%d/%d, got %s/%d/%d", i, c.name, c.kind, c.commentLine, items[i].Name, items[i].Kind, items[i].CommentLine)
+		}
+	}
+	start, end := f.ItemLines(items[1], true)
+	if got := src[start:end]; got != "  # about triggers\n  # more\n  triggers = {}\n" {
+		t.Errorf("unexpected item lines: %q", got)
+	}
+}
+
+func Test_GetCachesByContent(t *testing.T) {
+	a := Get("cache.tf", parse(t, "cache.tf", "locals {}\n"))
+	if b := Get("cache.tf", parse(t, "cache.tf", "locals {}\n")); a != b {
+		t.Errorf("expected the cached model to be reused for unchanged content")
+	}
+	if c := Get("cache.tf", parse(t, "cache.tf", "locals {}\n\n")); a == c {
+		t.Errorf("expected a new model after the content changed")
+	}
+}
@@ -24,6 +24,16 @@ func main() {
                 rules.NewStegraNoThisResourceNameRule(),
                 rules.NewStegraEmptyBlockOneLineRule(),
                 rules.NewStegraNoBlankLinesInRequiredProvidersRule(),
+                rules.NewStegraRequiredProvidersFormatRule(),
+                rules.NewStegraFilePlacementRule(),
+                rules.NewStegraBlockOrderRule(),
+                rules.NewStegraAttributesBeforeBlocksRule(),
+                rules.NewStegraVariableStructureRule(),
+                rules.NewStegraOutputStructureRule(),
+                rules.NewStegraSortedVariablesOutputsRule(),
+                rules.NewStegraNamingConventionRule(),
+                rules.NewStegraModuleSourcePinningRule(),
+                rules.NewStegraConsistentModuleVersionsRule(),
             },
         },
     })
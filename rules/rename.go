@@ -0,0 +1,214 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// This file holds the rename machinery shared by the rules that fix block names
+// (stegra_no_this_resource_name, stegra_no_type_in_name and stegra_naming_convention).
+
+// renameBlockFix returns a fix that renames the last label of a resource, data or module block to
+// newName, rewrites every expression reference to it and, for resources and module calls, records
+// a moved block in movedFile (or the block's file when movedFile is unset or missing). It also
+// reports whether any reference was found.
+func renameBlockFix(files map[string]*hcl.File, moved *movedBlocks, kind string, labels []string, nameRange hcl.Range, newName, movedFile string) (func(tflint.Fixer) error, bool) {
+	nameIdx := len(labels) - 1
+	name := labels[nameIdx]
+
+	// Collect AST-based reference hits for the block's traversals in all .tf files of this module
+	var hits []refHit
+	if kind == "module" {
+		hits = collectReferences(files, "module", name, false)
+	} else {
+		hits = collectReferences(files, labels[0], name, kind == "data")
+	}
+
+	// Data sources hold no state; resources and module calls get a moved block
+	movedTo := ""
+	var movedSrc []byte
+	if kind != "data" {
+		movedTo = nameRange.Filename
+		if movedFile != "" {
+			// The fixer can only edit existing files, so a missing moved file falls back to the block's file
+			target := filepath.Join(filepath.Dir(nameRange.Filename), movedFile)
+			if _, ok := files[target]; ok {
+				movedTo = target
+			}
+		}
+		if f, ok := files[movedTo]; ok {
+			movedSrc = f.Bytes
+		}
+	}
+	from := referenceAddress(kind, labels)
+	to := referenceAddress(kind, append(append([]string(nil), labels[:nameIdx]...), newName))
+
+	return func(fixer tflint.Fixer) error {
+		if err := fixer.ReplaceText(nameRange, fmt.Sprintf("%q", newName)); err != nil {
+			return err
+		}
+		for _, h := range hits {
+			rng := hcl.Range{Filename: h.file, Start: hcl.Pos{Byte: h.startByte}, End: hcl.Pos{Byte: h.endByte}}
+			if err := fixer.ReplaceText(rng, "."+newName); err != nil {
+				return err
+			}
+		}
+		if movedTo != "" {
+			return moved.add(fixer, movedTo, movedSrc, from, to)
+		}
+		return nil
+	}, len(hits) > 0
+}
+
+// blockAddress identifies a top-level block by its type and labels, e.g. "resource.aws_vpc.main".
+func blockAddress(kind string, labels []string) string {
+	return kind + "." + strings.Join(labels, ".")
+}
+
+// takenAddresses returns the addresses of the top-level blocks in body. Rename rules add the
+// names claimed by the fixes they emit, so two fixes in one pass never pick the same address.
+func takenAddresses(body *hclext.BodyContent) map[string]struct{} {
+	taken := map[string]struct{}{}
+	for _, blk := range body.Blocks {
+		taken[blockAddress(blk.Type, blk.Labels)] = struct{}{}
+	}
+	return taken
+}
+
+// referenceAddress returns how expressions refer to a block: <type>.<name>, data.<type>.<name> or module.<name>.
+func referenceAddress(kind string, labels []string) string {
+	if kind == "resource" {
+		return strings.Join(labels, ".")
+	}
+	return kind + "." + strings.Join(labels, ".")
+}
+
+// refHit is the byte range of the ".<name>" step of a reference in file.
+type refHit struct {
+	file      string
+	startByte int
+	endByte   int
+}
+
+// collectReferences walks the expressions of all .tf files and returns every <type>.<name>
+// (or data.<type>.<name>) traversal; pass "module" as typ to find module.<name>. Strings and comments are not expressions and are left alone.
+func collectReferences(files map[string]*hcl.File, typ, name string, data bool) []refHit {
+	hits := []refHit{}
+	for fname, f := range files {
+		if filepath.Ext(fname) != ".tf" {
+			continue
+		}
+		if lf := layout.Get(fname, f); lf.Body != nil {
+			hclsyntax.Walk(lf.Body, &walkCollector{
+				wantType: typ,
+				wantName: name,
+				data:     data,
+				filename: fname,
+				content:  string(lf.Src),
+				onHit: func(startByte, endByte int) {
+					hits = append(hits, refHit{file: fname, startByte: startByte, endByte: endByte})
+				},
+			})
+		}
+	}
+	return hits
+}
+
+// movedBlocks appends moved blocks to the end of files. Only the first block appended to a file
+// needs a separator that depends on how the file ends; later ones follow a block ending in a newline.
+type movedBlocks struct {
+	appended map[string]bool
+}
+
+func newMovedBlocks() *movedBlocks { return &movedBlocks{appended: map[string]bool{}} }
+
+// add appends a moved block recording the rename of from to to at the end of filename, whose
+// original content is src.
+func (m *movedBlocks) add(fixer tflint.Fixer, filename string, src []byte, from, to string) error {
+	prefix := "\n"
+	switch {
+	case m.appended[filename]:
+	case len(src) == 0:
+		prefix = ""
+	case src[len(src)-1] != '\n':
+		prefix = "\n\n"
+	}
+	m.appended[filename] = true
+	eof := hcl.Pos{Byte: len(src)}
+	return fixer.InsertTextAfter(
+		hcl.Range{Filename: filename, Start: eof, End: eof},
+		fmt.Sprintf("%smoved {\n  from = %s\n  to   = %s\n}\n", prefix, from, to),
+	)
+}
+
+// walkCollector implements hclsyntax.Visitor to collect traversal refs of form <type>.<name>,
+// or data.<type>.<name> when data is set. onHit receives the byte range of the ".<name>" step.
+type walkCollector struct {
+	wantType string
+	wantName string
+	data     bool
+	filename string
+	content  string
+	onHit    func(startByte int, endByte int)
+}
+
+func (w *walkCollector) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	var tr hcl.Traversal
+	var rng hcl.Range
+	switch e := node.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		tr = e.Traversal
+		rng = e.Range()
+	case *hclsyntax.RelativeTraversalExpr:
+		tr = e.Traversal
+		rng = e.Range()
+	default:
+		return nil
+	}
+	prefix := []string{w.wantType}
+	if w.data {
+		prefix = []string{"data", w.wantType}
+	}
+	if len(tr) < len(prefix)+1 {
+		return nil
+	}
+	for i, want := range prefix {
+		if traversalStepName(tr[i]) != want {
+			return nil
+		}
+	}
+	if traversalStepName(tr[len(prefix)]) != w.wantName {
+		return nil
+	}
+	if rng.Start.Byte >= 0 && rng.End.Byte <= len(w.content) {
+		segment := w.content[rng.Start.Byte:rng.End.Byte]
+		head := strings.Join(prefix, ".")
+		needle := head + "." + w.wantName
+		if idx := strings.Index(segment, needle); idx >= 0 {
+			start := rng.Start.Byte + idx + len(head)
+			end := start + len("."+w.wantName)
+			w.onHit(start, end)
+		}
+	}
+	return nil
+}
+
+func (w *walkCollector) Exit(node hclsyntax.Node) hcl.Diagnostics { return nil }
+
+// traversalStepName returns the name of a root or attribute traversal step, or "" for other steps.
+func traversalStepName(t hcl.Traverser) string {
+	switch s := t.(type) {
+	case hcl.TraverseRoot:
+		return s.Name
+	case hcl.TraverseAttr:
+		return s.Name
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraAttributesBeforeBlocksRule requires the attributes of resource/data/module blocks to come
+// before their nested blocks, with a blank line between the last attribute and the first block.
+// Items named by the stegra_keywords_first keywords keep their leading slots, so the two rules
+// agree on one order.
+type StegraAttributesBeforeBlocksRule struct{ tflint.DefaultRule }
+
+func NewStegraAttributesBeforeBlocksRule() *StegraAttributesBeforeBlocksRule {
+	return &StegraAttributesBeforeBlocksRule{}
+}
+func (r *StegraAttributesBeforeBlocksRule) Name() string              { return "stegra_attributes_before_blocks" }
+func (r *StegraAttributesBeforeBlocksRule) Enabled() bool             { return true }
+func (r *StegraAttributesBeforeBlocksRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraAttributesBeforeBlocksRule) Link() string              { return "" }
+
+type stegraAttributesBeforeBlocksConfig struct {
+	BlockTypes []string `hclext:"block_types,optional"`
+	// Exclude names items that keep their position, such as depends_on (see stegra_depends_on_last)
+	Exclude []string `hclext:"exclude,optional"`
+}
+
+func (r *StegraAttributesBeforeBlocksRule) Check(runner tflint.Runner) error {
+	cfg := stegraAttributesBeforeBlocksConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	blockTypes := cfg.BlockTypes
+	if len(blockTypes) == 0 {
+		blockTypes = []string{"resource", "data", "module"}
+	}
+	checked := map[string]bool{}
+	for _, t := range blockTypes {
+		checked[t] = true
+	}
+	exclude := cfg.Exclude
+	if exclude == nil {
+		exclude = []string{"depends_on"}
+	}
+	excluded := map[string]bool{}
+	for _, n := range exclude {
+		excluded[n] = true
+	}
+	keywords := stegraKeywordsFirstConfig{}
+	_ = runner.DecodeRuleConfig(NewStegraKeywordsFirstRule().Name(), &keywords)
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		for _, blk := range lf.Body.Blocks {
+			if !checked[blk.Type] {
+				continue
+			}
+			leading := map[string]bool{}
+			for _, k := range keywords.forKind(blk.Type) {
+				leading[k] = true
+			}
+			if err := r.checkBlock(runner, lf, blk, excluded, leading); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *StegraAttributesBeforeBlocksRule) checkBlock(runner tflint.Runner, lf *layout.File, blk *hclsyntax.Block, excluded, leading map[string]bool) error {
+	items := lf.Items(blk.Body)
+
+	// Excluded and keyword items keep their slots; the other slots take the attributes, then the blocks
+	attrs, blocks, slots := []int{}, []int{}, []int{}
+	for i, it := range items {
+		if excluded[it.Name] || leading[it.Name] {
+			continue
+		}
+		slots = append(slots, i)
+		if it.Kind == layout.Attribute {
+			attrs = append(attrs, i)
+		} else {
+			blocks = append(blocks, i)
+		}
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	for n, idx := range append(attrs, blocks...) {
+		order[slots[n]] = idx
+	}
+
+	// The blank line goes after the last attribute, keyword attributes included, when a nested
+	// block follows it directly
+	boundary := -1
+	for pos, idx := range order {
+		if !excluded[items[idx].Name] && items[idx].Kind == layout.Attribute {
+			boundary = pos
+		}
+	}
+	next := boundary + 1
+	separated := boundary >= 0 && next < len(order) && items[order[next]].Kind == layout.Block && !excluded[items[order[next]].Name]
+	gapHasBlank := func(pos int) bool {
+		for l := items[pos].EndLine() + 1; l < items[pos+1].CommentLine; l++ {
+			if lf.IsBlank(l) {
+				return true
+			}
+		}
+		return false
+	}
+
+	misplaced := -1
+	for pos, idx := range order {
+		if pos != idx {
+			misplaced = pos
+			break
+		}
+	}
+	if misplaced < 0 {
+		// Ordered already; only the separating blank line may be missing
+		if !separated || gapHasBlank(boundary) {
+			return nil
+		}
+		first := items[next]
+		anchor := lf.LineAnchor(first.CommentLine)
+		if first.CommentLine <= items[boundary].EndLine() {
+			anchor = first.Block.TypeRange
+		}
+		return runner.EmitIssueWithFix(
+			r,
+			"nested blocks must be separated from the attributes by a blank line",
+			first.Block.TypeRange,
+			func(fixer tflint.Fixer) error { return fixer.InsertTextBefore(anchor, "\n") },
+		)
+	}
+
+	// Report the first attribute that follows a nested block
+	var late layout.Item
+	for _, idx := range attrs {
+		if idx > blocks[0] {
+			late = items[idx]
+			break
+		}
+	}
+	msg := fmt.Sprintf("attribute `%s` must come before nested blocks", late.Name)
+
+	// The block moved into the slot after the last attribute starts with a blank line unless the
+	// gap kept at that position already has one
+	prefixed := -1
+	if separated && !gapHasBlank(boundary) {
+		prefixed = next
+	}
+	rng, text, ok := reorderedItems(lf, blk, items, order, func(pos int, text string) string {
+		if pos == prefixed {
+			return "\n" + text
+		}
+		return text
+	})
+	if !ok {
+		return runner.EmitIssue(r, msg, late.Range)
+	}
+	return runner.EmitIssueWithFix(
+		r,
+		msg,
+		late.Range,
+		func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, text) },
+	)
+}
@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraAttributesBeforeBlocksRule(t *testing.T) {
+	rule := NewStegraAttributesBeforeBlocksRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {
+  name = "web"
+  ingress {
+    from_port = 443
+  }
+  # owning team
+  tags = {
+    Team = "x"
+  }
+
+  depends_on = [aws_vpc.main]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "attribute `tags` must come before nested blocks",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 7, Column: 3}, End: hcl.Pos{Line: 9, Column: 4}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {
+  name = "web"
+  # owning team
+  tags = {
+    Team = "x"
+  }
+
+  ingress {
+    from_port = 443
+  }
+
+  depends_on = [aws_vpc.main]
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraAttributesBeforeBlocksRule_BlankLine(t *testing.T) {
+	rule := NewStegraAttributesBeforeBlocksRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_instance" "web" {
+  ami = "ami-123"
+  # root disk
+  root_block_device {
+    volume_size = 20
+  }
+}
+
+resource "aws_instance" "ok" {
+  ami = "ami-123"
+
+  root_block_device {}
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "nested blocks must be separated from the attributes by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 3}, End: hcl.Pos{Line: 4, Column: 20}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_instance" "web" {
+  ami = "ami-123"
+
+  # root disk
+  root_block_device {
+    volume_size = 20
+  }
+}
+
+resource "aws_instance" "ok" {
+  ami = "ami-123"
+
+  root_block_device {}
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraAttributesBeforeBlocksRule_KeywordsFirst(t *testing.T) {
+	rule := NewStegraAttributesBeforeBlocksRule()
+	config := `
+rule "stegra_keywords_first" {
+  enabled  = true
+  keywords = ["count", "lifecycle"]
+}
+`
+	files := map[string]string{
+		".tflint.hcl": config,
+		"main.tf": `resource "aws_security_group" "web" {
+  count = 1
+  lifecycle {
+    create_before_destroy = true
+  }
+  ingress {}
+  name = "web"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "attribute `name` must come before nested blocks",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 7, Column: 3}, End: hcl.Pos{Line: 7, Column: 15}},
+		},
+	}, runner.Issues)
+	fixed := `resource "aws_security_group" "web" {
+  count = 1
+  lifecycle {
+    create_before_destroy = true
+  }
+  name = "web"
+
+  ingress {}
+}
+`
+	helper.AssertChanges(t, map[string]string{"main.tf": fixed}, runner.Changes())
+
+	// The fixed block satisfies both rules, so their fixes do not undo each other
+	runner = helper.TestRunner(t, map[string]string{".tflint.hcl": config, "main.tf": fixed})
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	if err := NewStegraKeywordsFirstRule().Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+}
@@ -2,14 +2,14 @@ package rules
 
 import (
 	"path/filepath"
-	"strings"
 
-	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
-// StegraBlankLineBetweenBlocksRule enforces at least one blank line between resource, data, and module blocks.
+// StegraBlankLineBetweenBlocksRule enforces at least one blank line between sibling blocks: top-level
+// blocks of the configured types and, optionally, nested blocks.
 type StegraBlankLineBetweenBlocksRule struct{ tflint.DefaultRule }
 
 func NewStegraBlankLineBetweenBlocksRule() *StegraBlankLineBetweenBlocksRule {
@@ -20,7 +20,20 @@ func (r *StegraBlankLineBetweenBlocksRule) Enabled() bool             { return t
 func (r *StegraBlankLineBetweenBlocksRule) Severity() tflint.Severity { return tflint.ERROR }
 func (r *StegraBlankLineBetweenBlocksRule) Link() string              { return "" }
 
+type stegraBlankLineBetweenBlocksConfig struct {
+	BlockTypes []string `hclext:"block_types,optional"`
+	Nested     bool     `hclext:"nested,optional"`
+}
+
 func (r *StegraBlankLineBetweenBlocksRule) Check(runner tflint.Runner) error {
+	cfg := stegraBlankLineBetweenBlocksConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	// An empty set means every top-level block type
+	topLevel := map[string]bool{}
+	for _, t := range cfg.BlockTypes {
+		topLevel[t] = true
+	}
+
 	files, err := runner.GetFiles()
 	if err != nil {
 		return err
@@ -30,79 +43,82 @@ func (r *StegraBlankLineBetweenBlocksRule) Check(runner tflint.Runner) error {
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		body, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		lf := layout.Get(filename, file)
+		body := lf.Body
+		if body == nil {
 			continue
 		}
 
-		raw := string(file.Bytes)
-		lines := strings.Split(raw, "\n")
-		// Build line start byte offsets so we can construct precise anchors
-		lineStarts := make([]int, 1, len(raw)/16+2)
-		lineStarts[0] = 0
-		for i := 0; i < len(raw); i++ {
-			if raw[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
+		if err := r.checkSiblings(runner, lf, body, func(b *hclsyntax.Block) bool {
+			return len(topLevel) == 0 || topLevel[b.Type]
+		}); err != nil {
+			return err
+		}
+		if !cfg.Nested {
+			continue
+		}
+		var walkErr error
+		walkBodyBlocks(body, func(blk *hclsyntax.Block) {
+			if walkErr == nil {
+				walkErr = r.checkSiblings(runner, lf, blk.Body, func(*hclsyntax.Block) bool { return true })
 			}
+		})
+		if walkErr != nil {
+			return walkErr
 		}
+	}
+
+	return nil
+}
 
-		// Filter to only the block types we care about and keep source order
-		blocks := make([]*hclsyntax.Block, 0, len(body.Blocks))
-		for _, b := range body.Blocks {
-			switch b.Type {
-			case "resource", "data", "module":
-				blocks = append(blocks, b)
-			default:
-				// ignore other block types
+// checkSiblings requires a blank line between each pair of directly consecutive blocks in body
+// that are both included. Blocks separated by an attribute are not siblings in this sense.
+func (r *StegraBlankLineBetweenBlocksRule) checkSiblings(runner tflint.Runner, lf *layout.File, body *hclsyntax.Body, include func(*hclsyntax.Block) bool) error {
+	items := lf.Items(body)
+	for i := 1; i < len(items); i++ {
+		if items[i-1].Kind != layout.Block || items[i].Kind != layout.Block {
+			continue
+		}
+		prev := items[i-1].Block
+		next := items[i].Block
+		if !include(prev) || !include(next) {
+			continue
+		}
+		prevCloseLine := prev.CloseBraceRange.End.Line
+		nextStartLine := next.TypeRange.Start.Line
+		// Analyze the region between the two blocks
+		regionStart := prevCloseLine + 1
+		regionEnd := nextStartLine - 1
+		hasBlank := false
+		firstNonBlank := 0
+		for ln := regionStart; ln <= regionEnd; ln++ {
+			if lf.IsBlank(ln) {
+				hasBlank = true
+				break
+			}
+			if firstNonBlank == 0 {
+				firstNonBlank = ln
 			}
 		}
-
-		for i := 1; i < len(blocks); i++ {
-			prev := blocks[i-1]
-			next := blocks[i]
-			prevCloseLine := prev.CloseBraceRange.End.Line
-			nextStartLine := next.TypeRange.Start.Line
-			// Analyze the region between the two blocks
-			regionStart := prevCloseLine + 1
-			regionEnd := nextStartLine - 1
-			hasBlank := false
-			firstNonBlank := 0
-			for ln := regionStart; ln <= regionEnd; ln++ {
-				if ln >= 1 && ln <= len(lines) {
-					if strings.TrimSpace(lines[ln-1]) == "" {
-						hasBlank = true
-						break
-					}
-					if firstNonBlank == 0 && strings.TrimSpace(lines[ln-1]) != "" {
-						firstNonBlank = ln
-					}
-				}
+		if !hasBlank {
+			// If there is a comment group right before the next block, we still require a blank line,
+			// but insert it before the first comment so comments stay attached to the next block.
+			// Decide insertion anchor: if we have any line between blocks, insert before the first non-blank line,
+			// otherwise insert before the next block itself.
+			anchor := next.TypeRange
+			if firstNonBlank != 0 {
+				// Zero-width anchor at the start of the firstNonBlank line
+				anchor = lf.LineAnchor(firstNonBlank)
 			}
-			if !hasBlank {
-				// If there is a comment group right before the next block, we still require a blank line,
-				// but insert it before the first comment so comments stay attached to the next block.
-				// Decide insertion anchor: if we have any line between blocks, insert before the first non-blank line,
-				// otherwise insert before the next block itself.
-				anchor := next.TypeRange
-				if firstNonBlank != 0 {
-					// Build a zero-width anchor at the start of the firstNonBlank line with correct byte offsets
-					byteOff := 0
-					if firstNonBlank-1 < len(lineStarts) {
-						byteOff = lineStarts[firstNonBlank-1]
-					}
-					anchor = hcl.Range{Filename: filename, Start: hcl.Pos{Line: firstNonBlank, Column: 1, Byte: byteOff}, End: hcl.Pos{Line: firstNonBlank, Column: 1, Byte: byteOff}}
-				}
-				if err := runner.EmitIssueWithFix(
-					r,
-					"blocks must be separated by a blank line",
-					next.TypeRange,
-					func(fixer tflint.Fixer) error { return fixer.InsertTextBefore(anchor, "\n") },
-				); err != nil {
-					return err
-				}
+			if err := runner.EmitIssueWithFix(
+				r,
+				"blocks must be separated by a blank line",
+				next.TypeRange,
+				func(fixer tflint.Fixer) error { return fixer.InsertTextBefore(anchor, "\n") },
+			); err != nil {
+				return err
 			}
 		}
 	}
-
 	return nil
 }
@@ -93,3 +93,104 @@ func Test_StegraBlankLineBetweenBlocksRule_Fix_WithCommentBetweenBlocks(t *testi
 		"main.tf": "resource \"null_resource\" \"a\" {}\n\n# comment about next\nresource \"null_resource\" \"b\" {}\n",
 	}, runner.Changes())
 }
+
+func Test_StegraBlankLineBetweenBlocksRule_BlockTypes(t *testing.T) {
+	rule := NewStegraBlankLineBetweenBlocksRule()
+	src := "variable \"a\" {}\nvariable \"b\" {}\noutput \"c\" {\n  value = 1\n}\nlocals {}\n"
+
+	// Every top-level block type is checked by default
+	runner := helper.TestRunner(t, map[string]string{"main.tf": src})
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "blocks must be separated by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 9}},
+		},
+		{
+			Rule:    rule,
+			Message: "blocks must be separated by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 1}, End: hcl.Pos{Line: 3, Column: 7}},
+		},
+		{
+			Rule:    rule,
+			Message: "blocks must be separated by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 6, Column: 1}, End: hcl.Pos{Line: 6, Column: 7}},
+		},
+	}, runner.Issues)
+
+	// Only pairs of the listed types are checked
+	runner = helper.TestRunner(t, map[string]string{
+		"main.tf": src,
+		".tflint.hcl": `
+rule "stegra_blank_line_between_blocks" {
+  enabled     = true
+  block_types = ["output", "locals"]
+}
+`,
+	})
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "blocks must be separated by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 6, Column: 1}, End: hcl.Pos{Line: 6, Column: 7}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraBlankLineBetweenBlocksRule_Nested(t *testing.T) {
+	rule := NewStegraBlankLineBetweenBlocksRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {
+  name = "web"
+  ingress {
+    from_port = 80
+  }
+  # https
+  ingress {
+    from_port = 443
+  }
+  tags = {}
+  egress {}
+}
+`,
+		".tflint.hcl": `
+rule "stegra_blank_line_between_blocks" {
+  enabled = true
+  nested  = true
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "blocks must be separated by a blank line",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 7, Column: 3}, End: hcl.Pos{Line: 7, Column: 10}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {
+  name = "web"
+  ingress {
+    from_port = 80
+  }
+
+  # https
+  ingress {
+    from_port = 443
+  }
+  tags = {}
+  egress {}
+}
+`,
+	}, runner.Changes())
+}
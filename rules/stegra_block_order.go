@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraBlockOrderRule enforces a configured order of top-level block kinds in each file and,
+// optionally, sorts blocks of the same kind by their labels.
+type StegraBlockOrderRule struct{ tflint.DefaultRule }
+
+func NewStegraBlockOrderRule() *StegraBlockOrderRule      { return &StegraBlockOrderRule{} }
+func (r *StegraBlockOrderRule) Name() string              { return "stegra_block_order" }
+func (r *StegraBlockOrderRule) Enabled() bool             { return true }
+func (r *StegraBlockOrderRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraBlockOrderRule) Link() string              { return "" }
+
+type stegraBlockOrderConfig struct {
+	Order          []string `hclext:"order,optional"`
+	SortWithinKind bool     `hclext:"sort_within_kind,optional"`
+}
+
+var defaultBlockOrder = []string{"terraform", "provider", "variable", "locals", "data", "resource", "module", "output"}
+
+func (r *StegraBlockOrderRule) Check(runner tflint.Runner) error {
+	cfg := stegraBlockOrderConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	kinds := cfg.Order
+	if len(kinds) == 0 {
+		kinds = defaultBlockOrder
+	}
+	// Kinds that are not listed go after the listed ones, in source order
+	rank := map[string]int{}
+	for i, k := range kinds {
+		if _, dup := rank[k]; !dup {
+			rank[k] = i
+		}
+	}
+	// Variables sort like stegra_sorted_variables_outputs does, required ones first when it asks so
+	sortedCfg := stegraSortedVariablesOutputsConfig{}
+	_ = runner.DecodeRuleConfig(NewStegraSortedVariablesOutputsRule().Name(), &sortedCfg)
+	rankOf := func(kind string) int {
+		if rk, ok := rank[kind]; ok {
+			return rk
+		}
+		return len(kinds)
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		items := lf.Items(lf.Body)
+
+		order := make([]int, len(items))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			ia, ib := items[order[a]], items[order[b]]
+			if ra, rb := rankOf(ia.Name), rankOf(ib.Name); ra != rb {
+				return ra < rb
+			}
+			if cfg.SortWithinKind && ia.Kind == layout.Block && ib.Kind == layout.Block && ia.Name == ib.Name {
+				return namedBlockBefore(ia.Block, ib.Block, sortedCfg.RequiredFirst)
+			}
+			return false
+		})
+
+		misplaced := -1
+		for pos, idx := range order {
+			if pos != idx {
+				misplaced = pos
+				break
+			}
+		}
+		if misplaced < 0 {
+			continue
+		}
+
+		it := items[misplaced]
+		var msg string
+		if items[order[misplaced]].Name != it.Name {
+			present := []string{}
+			for i, idx := range order {
+				if i == 0 || items[order[i-1]].Name != items[idx].Name {
+					present = append(present, items[idx].Name)
+				}
+			}
+			msg = fmt.Sprintf("top-level blocks must be ordered by kind: %s", strings.Join(present, ", "))
+		} else {
+			msg = fmt.Sprintf("%s blocks must be sorted by type and name", it.Name)
+		}
+		issueRange := it.Range
+		if it.Kind == layout.Block {
+			issueRange = it.Block.TypeRange
+		}
+
+		rng, text, ok := reorderedBlocks(lf, items, order)
+		if !ok {
+			if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := runner.EmitIssueWithFix(
+			r,
+			msg,
+			issueRange,
+			func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, text) },
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reorderedBlocks returns the range covering the moved top-level items and their direct
+// neighbours, and its text with the items in the given order. Each block moves with the comment
+// group directly above it, as in stegra_blank_line_between_blocks. Blank-only gaps next to a moved
+// item become a single blank line; every other gap, including those holding detached comments,
+// stays as it is. It reports false when two items share a line or nothing moves.
+func reorderedBlocks(lf *layout.File, items []layout.Item, order []int) (hcl.Range, string, bool) {
+	lo, hi := -1, -1
+	for pos, idx := range order {
+		if pos != idx {
+			if lo < 0 {
+				lo = pos
+			}
+			hi = pos
+		}
+	}
+	if lo < 0 {
+		return hcl.Range{}, "", false
+	}
+	spans := make([][2]int, len(items))
+	texts := make([]string, len(items))
+	for i, it := range items {
+		if i > 0 && it.CommentLine <= items[i-1].EndLine() {
+			return hcl.Range{}, "", false
+		}
+		spans[i][0], spans[i][1] = lf.ItemLines(it, true)
+		texts[i] = string(lf.Src[spans[i][0]:spans[i][1]])
+		if !strings.HasSuffix(texts[i], "\n") {
+			texts[i] += "\n"
+		}
+	}
+
+	// The unmoved neighbours are included so the gaps next to moved items can be rewritten
+	if lo > 0 {
+		lo--
+	}
+	if hi < len(items)-1 {
+		hi++
+	}
+	var sb strings.Builder
+	for pos := lo; pos <= hi; pos++ {
+		sb.WriteString(texts[order[pos]])
+		if pos == hi {
+			break
+		}
+		gap := string(lf.Src[spans[pos][1]:spans[pos+1][0]])
+		moved := order[pos] != pos || order[pos+1] != pos+1
+		if moved && strings.TrimSpace(gap) == "" {
+			gap = "\n"
+		}
+		sb.WriteString(gap)
+	}
+	return lf.LinesRange(items[lo].CommentLine, items[hi].EndLine()+1), sb.String(), true
+}
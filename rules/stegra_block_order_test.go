@@ -0,0 +1,194 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraBlockOrderRule(t *testing.T) {
+	rule := NewStegraBlockOrderRule()
+	files := map[string]string{
+		"main.tf": `# Network
+resource "aws_vpc" "main" {}
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+
+
+# Shared values
+locals {
+  name = "x"
+}
+provider "aws" {}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "top-level blocks must be ordered by kind: provider, locals, resource, output",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 9}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `provider "aws" {}
+
+# Shared values
+locals {
+  name = "x"
+}
+
+# Network
+resource "aws_vpc" "main" {}
+
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraBlockOrderRule_KeepsUnrelatedGaps(t *testing.T) {
+	rule := NewStegraBlockOrderRule()
+	files := map[string]string{
+		"main.tf": `variable "a" {}
+variable "b" {}
+
+
+locals {
+  name = "x"
+}
+output "x" {
+  value = 1
+}
+resource "null_resource" "r" {}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	// Only the gaps around the swapped output and resource change
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `variable "a" {}
+variable "b" {}
+
+
+locals {
+  name = "x"
+}
+
+resource "null_resource" "r" {}
+
+output "x" {
+  value = 1
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraBlockOrderRule_SortWithinKind(t *testing.T) {
+	rule := NewStegraBlockOrderRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_vpc" "main" {}
+
+resource "aws_subnet" "b" {}
+
+resource "aws_subnet" "a" {}
+
+module "net" {
+  source = "./net"
+}
+`,
+		".tflint.hcl": `
+rule "stegra_block_order" {
+  enabled          = true
+  order            = ["module", "resource"]
+  sort_within_kind = true
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "top-level blocks must be ordered by kind: module, resource",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `module "net" {
+  source = "./net"
+}
+
+resource "aws_subnet" "a" {}
+
+resource "aws_subnet" "b" {}
+
+resource "aws_vpc" "main" {}
+`,
+	}, runner.Changes())
+
+	// Only the order within a kind is wrong
+	files["main.tf"] = "resource \"aws_subnet\" \"b\" {}\n\nresource \"aws_subnet\" \"a\" {}\n"
+	runner = helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource blocks must be sorted by type and name",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraBlockOrderRule_RequiredVariablesFirst(t *testing.T) {
+	files := map[string]string{
+		"variables.tf": `variable "a" {
+  default = 1
+}
+
+variable "b" {}
+`,
+		".tflint.hcl": `
+rule "stegra_block_order" {
+  enabled          = true
+  sort_within_kind = true
+}
+
+rule "stegra_sorted_variables_outputs" {
+  enabled        = true
+  required_first = true
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := NewStegraSortedVariablesOutputsRule().Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	fixed := `variable "b" {}
+
+variable "a" {
+  default = 1
+}
+`
+	helper.AssertChanges(t, map[string]string{"variables.tf": fixed}, runner.Changes())
+
+	// stegra_block_order accepts the order stegra_sorted_variables_outputs asks for
+	files["variables.tf"] = fixed
+	runner = helper.TestRunner(t, files)
+	if err := NewStegraBlockOrderRule().Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+}
@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraConsistentModuleVersionsRule requires every call of the same module source to use the same
+// `version` (registry sources) or `ref` (git sources), so one plan never mixes module versions.
+type StegraConsistentModuleVersionsRule struct{ tflint.DefaultRule }
+
+func NewStegraConsistentModuleVersionsRule() *StegraConsistentModuleVersionsRule {
+	return &StegraConsistentModuleVersionsRule{}
+}
+func (r *StegraConsistentModuleVersionsRule) Name() string {
+	return "stegra_consistent_module_versions"
+}
+func (r *StegraConsistentModuleVersionsRule) Enabled() bool             { return true }
+func (r *StegraConsistentModuleVersionsRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraConsistentModuleVersionsRule) Link() string              { return "" }
+
+type stegraConsistentModuleVersionsConfig struct {
+	// CanonicalVersions maps a module source to the version (or git ref) every call must use;
+	// sources are compared after normalization (see parseModuleSource)
+	CanonicalVersions map[string]string `hclext:"canonical_versions,optional"`
+}
+
+// moduleCall is one module block with a versioned source.
+type moduleCall struct {
+	name    string
+	version string
+	// key is the version used for comparison; constraints are normalized so that "~>5.8" and
+	// "~> 5.8" or "= 5.8.1" and "5.8.1" are the same version
+	key string
+	rng hcl.Range
+}
+
+// versionConstraintPattern splits one constraint of a version constraint string into its operator
+// and version.
+var versionConstraintPattern = regexp.MustCompile(`^(=|!=|>=|<=|>|<|~>)?\s*(\S+)$`)
+
+// normalizeVersionConstraint writes each constraint as "<op> <version>", drops the implied "="
+// operator and joins the constraints with ", ". Constraints it does not understand are kept as is.
+func normalizeVersionConstraint(constraint string) string {
+	parts := strings.Split(constraint, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		m := versionConstraintPattern.FindStringSubmatch(part)
+		switch {
+		case m == nil:
+			parts[i] = part
+		case m[1] == "" || m[1] == "=":
+			parts[i] = m[2]
+		default:
+			parts[i] = m[1] + " " + m[2]
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *StegraConsistentModuleVersionsRule) Check(runner tflint.Runner) error {
+	cfg := stegraConsistentModuleVersionsConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	canonical := map[string]string{}
+	for src, v := range cfg.CanonicalVersions {
+		if ms := parseModuleSource(src); ms.class == "registry" {
+			canonical[ms.normalized] = normalizeVersionConstraint(v)
+		} else {
+			canonical[ms.normalized] = strings.TrimSpace(v)
+		}
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	// Calls grouped by normalized source, in file and source order
+	groups := map[string][]moduleCall{}
+	sources := []string{}
+	for _, filename := range filenames {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, files[filename])
+		if lf.Body == nil {
+			continue
+		}
+		for _, blk := range lf.Body.Blocks {
+			if blk.Type != "module" || len(blk.Labels) == 0 {
+				continue
+			}
+			srcAttr, ok := blk.Body.Attributes["source"]
+			if !ok {
+				continue
+			}
+			src, ok := staticString(srcAttr.Expr)
+			if !ok {
+				continue
+			}
+			ms := parseModuleSource(src)
+			call := moduleCall{name: blk.Labels[0]}
+			switch ms.class {
+			case "registry":
+				verAttr, ok := blk.Body.Attributes["version"]
+				if !ok {
+					continue
+				}
+				if call.version, ok = staticString(verAttr.Expr); !ok {
+					continue
+				}
+				call.version = strings.TrimSpace(call.version)
+				call.key = normalizeVersionConstraint(call.version)
+				call.rng = verAttr.Expr.Range()
+			case "git":
+				if !ms.hasRef {
+					continue
+				}
+				call.version = ms.ref
+				call.key = ms.ref
+				call.rng = srcAttr.Expr.Range()
+			default:
+				continue
+			}
+			if _, seen := groups[ms.normalized]; !seen {
+				sources = append(sources, ms.normalized)
+			}
+			groups[ms.normalized] = append(groups[ms.normalized], call)
+		}
+	}
+
+	for _, src := range sources {
+		calls := groups[src]
+		if want, ok := canonical[src]; ok {
+			for _, c := range calls {
+				if c.key == want {
+					continue
+				}
+				msg := fmt.Sprintf("module `%s` must use version %q of %s, got %q", c.name, want, src, c.version)
+				if err := runner.EmitIssue(r, msg, c.rng); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// Versions are reported as written by the first call using them
+		counts := map[string]int{}
+		written := map[string]string{}
+		versions := []string{}
+		for _, c := range calls {
+			if counts[c.key] == 0 {
+				versions = append(versions, c.key)
+				written[c.key] = c.version
+			}
+			counts[c.key]++
+		}
+		if len(versions) < 2 {
+			continue
+		}
+		sort.SliceStable(versions, func(a, b int) bool { return counts[versions[a]] > counts[versions[b]] })
+		majority := versions[0]
+		if counts[versions[1]] == counts[majority] {
+			// No majority: every call is reported with the full list of versions
+			sort.Strings(versions)
+			quoted := make([]string, len(versions))
+			for i, v := range versions {
+				quoted[i] = fmt.Sprintf("%q", written[v])
+			}
+			for _, c := range calls {
+				msg := fmt.Sprintf("module `%s` uses version %q of %s, calls of this source use %s", c.name, c.version, src, strings.Join(quoted, ", "))
+				if err := runner.EmitIssue(r, msg, c.rng); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, c := range calls {
+			if c.key == majority {
+				continue
+			}
+			msg := fmt.Sprintf("module `%s` uses version %q of %s, most calls use %q", c.name, c.version, src, written[majority])
+			if err := runner.EmitIssue(r, msg, c.rng); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraConsistentModuleVersionsRule(t *testing.T) {
+	rule := NewStegraConsistentModuleVersionsRule()
+	files := map[string]string{
+		"a.tf": `module "vpc_a" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+
+module "net_a" {
+  source = "git::https://github.com/acme/net.git?ref=v1.0.0"
+}
+`,
+		"b.tf": `module "vpc_b" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+
+module "vpc_c" {
+  source  = "Terraform-AWS-Modules/vpc/aws"
+  version = "5.7.0"
+}
+
+module "net_b" {
+  source = "git::https://github.com/acme/net.git?ref=v1.1.0"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `net_a` uses version \"v1.0.0\" of https://github.com/acme/net.git, calls of this source use \"v1.0.0\", \"v1.1.0\"",
+			Range:   hcl.Range{Filename: "a.tf", Start: hcl.Pos{Line: 7, Column: 12}, End: hcl.Pos{Line: 7, Column: 61}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `vpc_c` uses version \"5.7.0\" of registry.terraform.io/terraform-aws-modules/vpc/aws, most calls use \"5.8.1\"",
+			Range:   hcl.Range{Filename: "b.tf", Start: hcl.Pos{Line: 8, Column: 13}, End: hcl.Pos{Line: 8, Column: 20}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `net_b` uses version \"v1.1.0\" of https://github.com/acme/net.git, calls of this source use \"v1.0.0\", \"v1.1.0\"",
+			Range:   hcl.Range{Filename: "b.tf", Start: hcl.Pos{Line: 12, Column: 12}, End: hcl.Pos{Line: 12, Column: 61}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraConsistentModuleVersionsRule_Canonical(t *testing.T) {
+	rule := NewStegraConsistentModuleVersionsRule()
+	files := map[string]string{
+		"main.tf": `module "vpc_a" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+
+module "vpc_b" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+`,
+		".tflint.hcl": `
+rule "stegra_consistent_module_versions" {
+  enabled = true
+
+  canonical_versions = {
+    "terraform-aws-modules/vpc/aws" = "5.9.0"
+  }
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssuesWithoutRange(t, helper.Issues{
+		{Rule: rule, Message: "module `vpc_a` must use version \"5.9.0\" of registry.terraform.io/terraform-aws-modules/vpc/aws, got \"5.8.1\""},
+		{Rule: rule, Message: "module `vpc_b` must use version \"5.9.0\" of registry.terraform.io/terraform-aws-modules/vpc/aws, got \"5.8.1\""},
+	}, runner.Issues)
+}
+
+func Test_StegraConsistentModuleVersionsRule_NormalizedConstraints(t *testing.T) {
+	rule := NewStegraConsistentModuleVersionsRule()
+	files := map[string]string{
+		"main.tf": `module "vpc_a" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~>5.8"
+}
+
+module "vpc_b" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.8"
+}
+
+module "eks_a" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "= 20.1.0"
+}
+
+module "eks_b" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "20.1.0"
+}
+
+module "eks_c" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "20.2.0"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `eks_c` uses version \"20.2.0\" of registry.terraform.io/terraform-aws-modules/eks/aws, most calls use \"= 20.1.0\"",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 23, Column: 13}, End: hcl.Pos{Line: 23, Column: 21}},
+		},
+	}, runner.Issues)
+}
@@ -1,15 +1,19 @@
 package rules
 
 import (
-    "path/filepath"
-    "strings"
-
-    "github.com/hashicorp/hcl/v2"
-    "github.com/hashicorp/hcl/v2/hclsyntax"
-    "github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
-// StegraDependsOnLastRule ensures depends_on is the last attribute in resource/data blocks.
+// StegraDependsOnLastRule ensures the configured trailing items (by default only depends_on)
+// close resource/data/module blocks in the configured order, after a blank line.
 type StegraDependsOnLastRule struct {
 	tflint.DefaultRule
 }
@@ -39,184 +43,207 @@ func (r *StegraDependsOnLastRule) Link() string {
 	return ""
 }
 
-// Check validates that depends_on, if present, is the last attribute within resource/data blocks.
+// stegraDependsOnLastConfig lists the attributes or nested block types that form the trailing
+// section of a block, in the order they must appear.
+type stegraDependsOnLastConfig struct {
+	Trailing []string `hclext:"trailing,optional"`
+}
+
+// Check validates that the trailing items, if present, close resource/data/module blocks.
 func (r *StegraDependsOnLastRule) Check(runner tflint.Runner) error {
-    files, err := runner.GetFiles()
-    if err != nil {
-        return err
-    }
-
-    for filename, file := range files {
-        if filepath.Ext(filename) != ".tf" {
-            continue
-        }
-        body, ok := file.Body.(*hclsyntax.Body)
-        if !ok {
-            continue
-        }
-
-        raw := string(file.Bytes)
-        lines := strings.Split(raw, "\n")
-        // Build line start byte offsets for safe slicing
-        lineStarts := make([]int, 1, len(raw)/16+2)
-        lineStarts[0] = 0
-        for i := 0; i < len(raw); i++ {
-            if raw[i] == '\n' {
-                lineStarts = append(lineStarts, i+1)
-            }
-        }
-
-        for _, blk := range body.Blocks {
-            if blk.Type != "resource" && blk.Type != "data" && blk.Type != "module" {
-                continue
-            }
-
-            attrs := blk.Body.Attributes
-            dep, ok := attrs["depends_on"]
-            if !ok {
-                continue
-            }
-
-            depRange := hcl.Range{Filename: filename, Start: dep.NameRange.Start, End: dep.Expr.Range().End}
-            depEnd := dep.Expr.Range().End
-
-            // Determine if any attribute or child block starts after depends_on ends
-            hasAttrAfter := false
-            for name, a := range attrs {
-                if name == "depends_on" {
-                    continue
-                }
-                start := a.NameRange.Start
-                if start.Byte > depEnd.Byte {
-                    hasAttrAfter = true
-                    break
-                }
-            }
-
-            hasBlockAfter := false
-            for _, child := range blk.Body.Blocks {
-                // Compare the start byte of the child block type keyword
-                start := child.TypeRange.Start
-                if start.Byte > depEnd.Byte {
-                    hasBlockAfter = true
-                    break
-                }
-            }
-
-            if !(hasAttrAfter || hasBlockAfter) {
-                // Enforce a blank line before the depends_on "section": contiguous comments above depends_on belong to the section
-                hasBefore := false
-                for name, a := range attrs {
-                    if name == "depends_on" {
-                        continue
-                    }
-                    if a.NameRange.Start.Byte < depRange.Start.Byte {
-                        hasBefore = true
-                        break
-                    }
-                }
-                if !hasBefore {
-                    for _, cb := range blk.Body.Blocks {
-                        if cb.TypeRange.Start.Byte < depRange.Start.Byte {
-                            hasBefore = true
-                            break
-                        }
-                    }
-                }
-                if hasBefore {
-                    // find top of comment section above depends_on
-                    topLine := depRange.Start.Line
-                    for l := depRange.Start.Line - 1; l >= 1; l-- {
-                        s := strings.TrimSpace(lines[l-1])
-                        if strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
-                            topLine = l
-                            continue
-                        }
-                        break
-                    }
-                    prevLine := topLine - 1
-                    needsBlank := false
-                    if prevLine >= 1 && prevLine <= len(lines) {
-                        if strings.TrimSpace(lines[prevLine-1]) != "" {
-                            needsBlank = true
-                        }
-                    }
-                    if needsBlank {
-                        anchor := hcl.Range{Filename: filename, Start: hcl.Pos{Line: topLine, Column: 1, Byte: lineStarts[topLine-1]}, End: hcl.Pos{Line: topLine, Column: 1, Byte: lineStarts[topLine-1]}}
-                        if err := runner.EmitIssueWithFix(
-                            r,
-                            "depends_on must be preceded by a blank line",
-                            depRange,
-                            func(fixer tflint.Fixer) error { return fixer.InsertTextBefore(anchor, "\n") },
-                        ); err != nil {
-                            return err
-                        }
-                    }
-                }
-                continue
-            }
-
-            // Prepare fix: move depends_on to the end of the block (before closing brace)
-            // Delete original depends_on including trailing newline
-            depStartByte := dep.NameRange.Start.Byte
-            endLine := depRange.End.Line
-            depEndByte := len(raw)
-            if endLine < len(lineStarts) {
-                depEndByte = lineStarts[endLine]
-            }
-            delRange := hcl.Range{Filename: filename, Start: hcl.Pos{Line: depRange.Start.Line, Column: depRange.Start.Column, Byte: depStartByte}, End: hcl.Pos{Line: depRange.End.Line, Column: depRange.End.Column, Byte: depEndByte}}
-            moveText := raw[depStartByte:depEndByte]
-            insertBefore := hcl.Range{Filename: filename, Start: blk.CloseBraceRange.Start, End: blk.CloseBraceRange.End}
-            // Ensure exactly one blank line before the depends_on "section": if comments exist at the end, insert the blank line before the first comment
-            closeStartLine := blk.CloseBraceRange.Start.Line
-            // find trailing comment group prior to the closing brace
-            commentTop := 0
-            for l := closeStartLine - 1; l >= 1; l-- {
-                s := strings.TrimSpace(lines[l-1])
-                if strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
-                    commentTop = l
-                    continue
-                }
-                break
-            }
-            // decide where to ensure blank line: before commentTop if any, else before the inserted depends_on
-            ensureLine := closeStartLine
-            if commentTop > 0 {
-                ensureLine = commentTop
-            }
-            needInsertBlank := false
-            var blankAnchor hcl.Range
-            if ensureLine-1 >= 1 && ensureLine-1 <= len(lines) {
-                if strings.TrimSpace(lines[ensureLine-2]) != "" {
-                    needInsertBlank = true
-                    blankAnchor = hcl.Range{Filename: filename, Start: hcl.Pos{Line: ensureLine, Column: 1, Byte: lineStarts[ensureLine-1]}, End: hcl.Pos{Line: ensureLine, Column: 1, Byte: lineStarts[ensureLine-1]}}
-                }
-            }
-
-            msg := "depends_on must be the last item in this block"
-            if hasAttrAfter && !hasBlockAfter {
-                msg = "depends_on must be the last attribute in this block"
-            }
-            if err := runner.EmitIssueWithFix(
-                r,
-                msg,
-                depRange,
-                func(fixer tflint.Fixer) error {
-                    if needInsertBlank {
-                        if err := fixer.InsertTextBefore(blankAnchor, "\n"); err != nil {
-                            return err
-                        }
-                    }
-                    if err := fixer.InsertTextBefore(insertBefore, moveText); err != nil {
-                        return err
-                    }
-                    return fixer.ReplaceText(delRange, "")
-                },
-            ); err != nil {
-                return err
-            }
-        }
-    }
-
-    return nil
+	cfg := stegraDependsOnLastConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	trailing := cfg.Trailing
+	if len(trailing) == 0 {
+		trailing = []string{"depends_on"}
+	}
+	rank := make(map[string]int, len(trailing))
+	for i, name := range trailing {
+		if _, dup := rank[name]; !dup {
+			rank[name] = i
+		}
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		body := lf.Body
+		if body == nil {
+			continue
+		}
+
+		for _, blk := range body.Blocks {
+			if blk.Type != "resource" && blk.Type != "data" && blk.Type != "module" {
+				continue
+			}
+			if err := r.checkBlock(runner, lf, blk, rank); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *StegraDependsOnLastRule) checkBlock(runner tflint.Runner, lf *layout.File, blk *hclsyntax.Block, rank map[string]int) error {
+	items := lf.Items(blk.Body)
+
+	// The kept section is the longest run at the end of the block made only of trailing items
+	// in configured order; every other trailing item has to move.
+	keep := len(items)
+	for keep > 0 {
+		rk, ok := rank[items[keep-1].Name]
+		if !ok || (keep < len(items) && rk > rank[items[keep].Name]) {
+			break
+		}
+		keep--
+	}
+	moved := []int{}
+	for i := 0; i < keep; i++ {
+		if _, ok := rank[items[i].Name]; ok {
+			moved = append(moved, i)
+		}
+	}
+
+	if len(moved) == 0 {
+		// Enforce a blank line before the trailing section: contiguous comments above it belong to the section
+		if keep == 0 || keep == len(items) {
+			return nil
+		}
+		first := items[keep]
+		if lf.IsBlank(first.CommentLine - 1) {
+			return nil
+		}
+		anchor := lf.LineAnchor(first.CommentLine)
+		return runner.EmitIssueWithFix(
+			r,
+			fmt.Sprintf("%s must be preceded by a blank line", first.Name),
+			trailingIssueRange(first),
+			func(fixer tflint.Fixer) error { return fixer.InsertTextBefore(anchor, "\n") },
+		)
+	}
+
+	closeLine := blk.CloseBraceRange.Start.Line
+	fixable := items[0].CommentLine > blk.OpenBraceRange.End.Line && items[len(items)-1].EndLine() < closeLine
+
+	// Where the trailing section will start: the kept section, or the closing brace. If comments
+	// sit right before the closing brace, the section starts at the first of them.
+	sectionLine := closeLine
+	if keep < len(items) {
+		sectionLine = items[keep].CommentLine
+	} else {
+		for sectionLine-1 > items[len(items)-1].EndLine() && lf.IsComment(sectionLine-1) {
+			sectionLine--
+		}
+	}
+	deleted := map[int]bool{}
+	for _, i := range moved {
+		for l := items[i].CommentLine; l <= items[i].EndLine(); l++ {
+			deleted[l] = true
+		}
+	}
+	prev := sectionLine - 1
+	for deleted[prev] {
+		prev--
+	}
+	needBlank := prev > blk.OpenBraceRange.End.Line && !lf.IsBlank(prev)
+
+	// Each moved item goes before the first kept item ranked after it, or before the closing brace
+	byRank := append([]int(nil), moved...)
+	sort.SliceStable(byRank, func(i, j int) bool { return rank[items[byRank[i]].Name] < rank[items[byRank[j]].Name] })
+	inserts := map[int]string{}
+	if needBlank {
+		inserts[sectionLine] = "\n"
+	}
+	for _, i := range byRank {
+		anchor := closeLine
+		for j := keep; j < len(items); j++ {
+			if rank[items[j].Name] > rank[items[i].Name] {
+				anchor = items[j].CommentLine
+				break
+			}
+		}
+		start, end := lf.ItemLines(items[i], true)
+		inserts[anchor] += string(lf.Src[start:end])
+	}
+
+	fix := func(fixer tflint.Fixer) error {
+		for line, text := range inserts {
+			if err := fixer.InsertTextBefore(lf.LineAnchor(line), text); err != nil {
+				return err
+			}
+		}
+		for _, i := range moved {
+			if err := fixer.ReplaceText(lf.LinesRange(items[i].CommentLine, items[i].EndLine()+1), ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for n, i := range moved {
+		it := items[i]
+		hasAttrAfter, hasBlockAfter := false, false
+		for _, other := range items[i+1:] {
+			if other.Kind == layout.Attribute {
+				hasAttrAfter = true
+			} else {
+				hasBlockAfter = true
+			}
+		}
+		later := []string{}
+		for _, name := range sortedTrailingNames(items, rank) {
+			if rank[name] > rank[it.Name] {
+				later = append(later, name)
+			}
+		}
+
+		msg := fmt.Sprintf("%s must be the last item in this block", it.Name)
+		if len(later) > 0 {
+			msg = fmt.Sprintf("%s must only be followed by %s in this block", it.Name, strings.Join(later, ", "))
+		} else if hasAttrAfter && !hasBlockAfter {
+			msg = fmt.Sprintf("%s must be the last attribute in this block", it.Name)
+		}
+
+		// One fix per block rewrites every misplaced item at once
+		if n == 0 && fixable {
+			if err := runner.EmitIssueWithFix(r, msg, trailingIssueRange(it), fix); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := runner.EmitIssue(r, msg, trailingIssueRange(it)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedTrailingNames returns the distinct names of the trailing items present, in configured order.
+func sortedTrailingNames(items []layout.Item, rank map[string]int) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, it := range items {
+		if _, ok := rank[it.Name]; ok && !seen[it.Name] {
+			seen[it.Name] = true
+			names = append(names, it.Name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return rank[names[i]] < rank[names[j]] })
+	return names
+}
+
+// trailingIssueRange highlights an attribute as a whole and a block by its type keyword.
+func trailingIssueRange(it layout.Item) hcl.Range {
+	if it.Kind == layout.Block {
+		return it.Block.TypeRange
+	}
+	return it.Range
 }
@@ -179,3 +179,135 @@ func Test_StegraDependsOnLastRule_BlankLineBeforeWhenLast(t *testing.T) {
         "main.tf": "resource \"null_resource\" \"ex\" {\n  triggers = { a = 1 }\n\n  depends_on = [null_resource.other]\n}\n",
     }, runner.Changes())
 }
+
+func Test_StegraDependsOnLastRule_ConfiguredTrailingSection(t *testing.T) {
+    rule := NewStegraDependsOnLastRule()
+    cfg := `
+rule "stegra_depends_on_last" {
+  enabled  = true
+  trailing = ["lifecycle", "provisioner", "depends_on"]
+}
+`
+    cases := []struct {
+        Name     string
+        Content  string
+        Expected helper.Issues
+    }{
+        {
+            Name: "trailing section in order after a blank line",
+            Content: `
+resource "null_resource" "ex" {
+  triggers = { a = 1 }
+
+  lifecycle {}
+  provisioner "local-exec" {}
+  provisioner "local-exec" {}
+  depends_on = [null_resource.other]
+}
+`,
+            Expected: helper.Issues{},
+        },
+        {
+            Name: "trailing section without blank line",
+            Content: `
+resource "null_resource" "ex" {
+  triggers = { a = 1 }
+  lifecycle {}
+  depends_on = [null_resource.other]
+}
+`,
+            Expected: helper.Issues{
+                {
+                    Rule:    rule,
+                    Message: "lifecycle must be preceded by a blank line",
+                    Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 3}, End: hcl.Pos{Line: 4, Column: 12}},
+                },
+            },
+        },
+        {
+            Name: "trailing items out of order",
+            Content: `
+resource "null_resource" "ex" {
+  triggers = { a = 1 }
+
+  depends_on = [null_resource.other]
+  lifecycle {}
+}
+`,
+            Expected: helper.Issues{
+                {
+                    Rule:    rule,
+                    Message: "depends_on must be the last item in this block",
+                    Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 5, Column: 3}, End: hcl.Pos{Line: 5, Column: 37}},
+                },
+            },
+        },
+        {
+            Name: "trailing item before body",
+            Content: `
+resource "null_resource" "ex" {
+  lifecycle {}
+  triggers = { a = 1 }
+
+  depends_on = [null_resource.other]
+}
+`,
+            Expected: helper.Issues{
+                {
+                    Rule:    rule,
+                    Message: "lifecycle must only be followed by depends_on in this block",
+                    Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 3}, End: hcl.Pos{Line: 3, Column: 12}},
+                },
+            },
+        },
+    }
+
+    for _, tc := range cases {
+        t.Run(tc.Name, func(t *testing.T) {
+            runner := helper.TestRunner(t, map[string]string{".tflint.hcl": cfg, "main.tf": tc.Content})
+            if err := rule.Check(runner); err != nil {
+                t.Fatalf("Unexpected error occurred: %s", err)
+            }
+            helper.AssertIssues(t, tc.Expected, runner.Issues)
+        })
+    }
+}
+
+func Test_StegraDependsOnLastRule_Fix_MovesEachTrailingItem(t *testing.T) {
+    rule := NewStegraDependsOnLastRule()
+    files := map[string]string{
+        ".tflint.hcl": `
+rule "stegra_depends_on_last" {
+  enabled  = true
+  trailing = ["lifecycle", "provisioner", "depends_on"]
+}
+`,
+        "main.tf": `resource "null_resource" "ex" {
+  depends_on = [null_resource.other]
+  # keep the old one around
+  lifecycle {
+    create_before_destroy = true
+  }
+  triggers = { a = 1 }
+  provisioner "local-exec" {}
+}
+`,
+    }
+    runner := helper.TestRunner(t, files)
+    if err := rule.Check(runner); err != nil {
+        t.Fatalf("Unexpected error occurred: %s", err)
+    }
+    helper.AssertChanges(t, map[string]string{
+        "main.tf": `resource "null_resource" "ex" {
+  triggers = { a = 1 }
+
+  # keep the old one around
+  lifecycle {
+    create_before_destroy = true
+  }
+  provisioner "local-exec" {}
+  depends_on = [null_resource.other]
+}
+`,
+    }, runner.Changes())
+}
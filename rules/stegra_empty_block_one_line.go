@@ -6,11 +6,13 @@ import (
 
     "github.com/hashicorp/hcl/v2"
     "github.com/hashicorp/hcl/v2/hclsyntax"
+    "github.com/stegraab/tflint-ruleset-stegra/internal/layout"
     "github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
 // StegraEmptyBlockOneLineRule enforces that empty blocks use single-line form: `{}`.
-// Applies to all block kinds (resource, data, module, and nested blocks).
+// Applies to all block kinds (resource, data, module, and nested blocks) except `exclude_types`,
+// and with `collections = true` to empty object and tuple expressions such as `tags = {}`.
 type StegraEmptyBlockOneLineRule struct{ tflint.DefaultRule }
 
 func NewStegraEmptyBlockOneLineRule() *StegraEmptyBlockOneLineRule { return &StegraEmptyBlockOneLineRule{} }
@@ -19,7 +21,19 @@ func (r *StegraEmptyBlockOneLineRule) Enabled() bool                { return tru
 func (r *StegraEmptyBlockOneLineRule) Severity() tflint.Severity    { return tflint.ERROR }
 func (r *StegraEmptyBlockOneLineRule) Link() string                 { return "" }
 
+type stegraEmptyBlockOneLineConfig struct {
+    ExcludeTypes []string `hclext:"exclude_types,optional"`
+    Collections  bool     `hclext:"collections,optional"`
+}
+
 func (r *StegraEmptyBlockOneLineRule) Check(runner tflint.Runner) error {
+    cfg := stegraEmptyBlockOneLineConfig{}
+    _ = runner.DecodeRuleConfig(r.Name(), &cfg)
+    excluded := map[string]bool{}
+    for _, t := range cfg.ExcludeTypes {
+        excluded[t] = true
+    }
+
     files, err := runner.GetFiles()
     if err != nil {
         return err
@@ -29,17 +43,18 @@ func (r *StegraEmptyBlockOneLineRule) Check(runner tflint.Runner) error {
         if filepath.Ext(filename) != ".tf" {
             continue
         }
-        body, ok := file.Body.(*hclsyntax.Body)
-        if !ok {
+        lf := layout.Get(filename, file)
+        body := lf.Body
+        if body == nil {
             continue
         }
-        content := string(file.Bytes)
+        content := string(lf.Src)
 
         var walk func(b *hclsyntax.Body) error
         walk = func(b *hclsyntax.Body) error {
             for _, blk := range b.Blocks {
                 // Empty if no attributes and no child blocks
-                if len(blk.Body.Attributes) == 0 && len(blk.Body.Blocks) == 0 {
+                if !excluded[blk.Type] && len(blk.Body.Attributes) == 0 && len(blk.Body.Blocks) == 0 {
                     // Ensure the slice between braces has only whitespace (no comments)
                     start := blk.OpenBraceRange.End.Byte
                     end := blk.CloseBraceRange.Start.Byte
@@ -74,6 +89,70 @@ func (r *StegraEmptyBlockOneLineRule) Check(runner tflint.Runner) error {
         if err := walk(body); err != nil {
             return err
         }
+        if cfg.Collections {
+            if err := r.checkCollections(runner, filename, content, body, excluded); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// checkCollections reports empty object (`{}`) and tuple (`[]`) constructor expressions whose
+// brackets are on different lines. Brackets enclosing comments and the bodies of excluded block
+// types are left alone.
+func (r *StegraEmptyBlockOneLineRule) checkCollections(runner tflint.Runner, filename, content string, body *hclsyntax.Body, excluded map[string]bool) error {
+    type emptyLiteral struct {
+        open    hcl.Range
+        closeAt int
+        msg     string
+    }
+    literals := []emptyLiteral{}
+    visit := func(node hclsyntax.Node) hcl.Diagnostics {
+        switch e := node.(type) {
+        case *hclsyntax.ObjectConsExpr:
+            if len(e.Items) == 0 {
+                literals = append(literals, emptyLiteral{e.OpenRange, e.SrcRange.End.Byte - 1, "empty object must be on one line (use `{}`)"})
+            }
+        case *hclsyntax.TupleConsExpr:
+            if len(e.Exprs) == 0 {
+                literals = append(literals, emptyLiteral{e.OpenRange, e.SrcRange.End.Byte - 1, "empty list must be on one line (use `[]`)"})
+            }
+        }
+        return nil
+    }
+    var walk func(b *hclsyntax.Body)
+    walk = func(b *hclsyntax.Body) {
+        for _, attr := range b.Attributes {
+            hclsyntax.VisitAll(attr.Expr, visit)
+        }
+        for _, blk := range b.Blocks {
+            if !excluded[blk.Type] {
+                walk(blk.Body)
+            }
+        }
+    }
+    walk(body)
+
+    for _, lit := range literals {
+        start := lit.open.End.Byte
+        end := lit.closeAt
+        if start < 0 || end < start || end > len(content) {
+            continue
+        }
+        between := content[start:end]
+        if strings.TrimSpace(between) != "" || !strings.Contains(between, "\n") {
+            continue
+        }
+        rng := hcl.Range{Filename: filename, Start: hcl.Pos{Byte: start}, End: hcl.Pos{Byte: end}}
+        if err := runner.EmitIssueWithFix(
+            r,
+            lit.msg,
+            lit.open,
+            func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+        ); err != nil {
+            return err
+        }
     }
     return nil
 }
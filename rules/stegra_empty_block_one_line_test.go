@@ -91,3 +91,89 @@ func Test_StegraEmptyBlockOneLineRule_Fix(t *testing.T) {
         "main.tf": "resource \"random_uuid\" \"x\" {}\n",
     }, runner.Changes())
 }
+
+func Test_StegraEmptyBlockOneLineRule_Collections(t *testing.T) {
+    rule := NewStegraEmptyBlockOneLineRule()
+    files := map[string]string{
+        "main.tf": `resource "null_resource" "a" {
+  triggers = {
+  }
+  depends_on = [
+
+  ]
+  keep = [
+    # nothing yet
+  ]
+
+  lifecycle {
+    ignore_changes = [
+    ]
+  }
+}
+`,
+        ".tflint.hcl": `
+rule "stegra_empty_block_one_line" {
+  enabled       = true
+  exclude_types = ["lifecycle"]
+  collections   = true
+}
+`,
+    }
+    runner := helper.TestRunner(t, files)
+    if err := rule.Check(runner); err != nil {
+        t.Fatalf("Unexpected error occurred: %s", err)
+    }
+    helper.AssertIssues(t, helper.Issues{
+        {
+            Rule:    rule,
+            Message: "empty object must be on one line (use `{}`)",
+            Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 14}, End: hcl.Pos{Line: 2, Column: 15}},
+        },
+        {
+            Rule:    rule,
+            Message: "empty list must be on one line (use `[]`)",
+            Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 16}, End: hcl.Pos{Line: 4, Column: 17}},
+        },
+    }, runner.Issues)
+    helper.AssertChanges(t, map[string]string{
+        "main.tf": `resource "null_resource" "a" {
+  triggers   = {}
+  depends_on = []
+  keep = [
+    # nothing yet
+  ]
+
+  lifecycle {
+    ignore_changes = [
+    ]
+  }
+}
+`,
+    }, runner.Changes())
+
+    // Collections are off by default
+    files["main.tf"] = `resource "null_resource" "a" {
+  triggers = {
+  }
+
+  lifecycle {
+  }
+}
+`
+    files[".tflint.hcl"] = `
+rule "stegra_empty_block_one_line" {
+  enabled = true
+}
+`
+    runner = helper.TestRunner(t, files)
+    if err := rule.Check(runner); err != nil {
+        t.Fatalf("Unexpected error occurred: %s", err)
+    }
+    helper.AssertIssues(t, helper.Issues{
+        {
+            Rule:    rule,
+            Message: "empty block must be on one line (use `{}`)",
+            Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 5, Column: 3}, End: hcl.Pos{Line: 5, Column: 12}},
+        },
+    }, runner.Issues)
+}
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraFilePlacementRule requires top-level blocks of the configured types to live in files whose
+// names match the configured globs, e.g. variables in variables.tf.
+type StegraFilePlacementRule struct{ tflint.DefaultRule }
+
+func NewStegraFilePlacementRule() *StegraFilePlacementRule   { return &StegraFilePlacementRule{} }
+func (r *StegraFilePlacementRule) Name() string              { return "stegra_file_placement" }
+func (r *StegraFilePlacementRule) Enabled() bool             { return true }
+func (r *StegraFilePlacementRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraFilePlacementRule) Link() string              { return "" }
+
+type stegraFilePlacementConfig struct {
+	Files map[string][]string `hclext:"files,optional"`
+}
+
+// defaultFilePlacement is used when no `files` map is configured.
+var defaultFilePlacement = map[string][]string{
+	"variable":  {"variables.tf"},
+	"output":    {"outputs.tf"},
+	"terraform": {"versions.tf"},
+	"provider":  {"providers.tf"},
+	"moved":     {"moved.tf"},
+}
+
+func (r *StegraFilePlacementRule) Check(runner tflint.Runner) error {
+	cfg := stegraFilePlacementConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	placement := cfg.Files
+	if len(placement) == 0 {
+		placement = defaultFilePlacement
+	}
+	for typ, globs := range placement {
+		for _, g := range globs {
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("%s: invalid file pattern %q for %s blocks: %s", r.Name(), g, typ, err)
+			}
+		}
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		// Skip JSON
+		if strings.HasSuffix(filename, ".tf.json") || filepath.Ext(filename) == ".json" {
+			continue
+		}
+		body := layout.Get(filename, file).Body
+		if body == nil {
+			continue
+		}
+
+		base := filepath.Base(filename)
+		for _, blk := range body.Blocks {
+			globs, ok := placement[blk.Type]
+			if !ok || len(globs) == 0 {
+				continue
+			}
+			if matchesAnyGlob(base, globs) {
+				continue
+			}
+			msg := fmt.Sprintf("%s block belongs in %s", blk.Type, strings.Join(globs, " or "))
+			issueRange := hcl.Range{Filename: filename, Start: blk.TypeRange.Start, End: blk.TypeRange.End}
+			if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesAnyGlob reports whether name matches one of the globs.
+func matchesAnyGlob(name string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
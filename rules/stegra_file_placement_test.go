@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraFilePlacementRule(t *testing.T) {
+	rule := NewStegraFilePlacementRule()
+
+	t.Run("default placement", func(t *testing.T) {
+		files := map[string]string{
+			"main.tf": `variable "a" {}
+
+output "b" {
+  value = 1
+}
+
+resource "null_resource" "c" {}
+`,
+			"variables.tf": `variable "d" {}`,
+			"versions.tf":  `terraform {}`,
+			"providers.tf": `terraform {}`,
+		}
+		runner := helper.TestRunner(t, files)
+		if err := rule.Check(runner); err != nil {
+			t.Fatalf("Unexpected error occurred: %s", err)
+		}
+		helper.AssertIssues(t, helper.Issues{
+			{
+				Rule:    rule,
+				Message: "variable block belongs in variables.tf",
+				Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+			},
+			{
+				Rule:    rule,
+				Message: "output block belongs in outputs.tf",
+				Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 1}, End: hcl.Pos{Line: 3, Column: 7}},
+			},
+			{
+				Rule:    rule,
+				Message: "terraform block belongs in versions.tf",
+				Range:   hcl.Range{Filename: "providers.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 10}},
+			},
+		}, runner.Issues)
+	})
+
+	t.Run("configured globs", func(t *testing.T) {
+		files := map[string]string{
+			"variables_network.tf": `variable "a" {}`,
+			"modules/x/inputs.tf":  `variable "b" {}`,
+			"main.tf":              `output "c" { value = 1 }`,
+			".tflint.hcl": `
+rule "stegra_file_placement" {
+  enabled = true
+  files = {
+    variable = ["variables.tf", "variables_*.tf"]
+  }
+}
+`,
+		}
+		runner := helper.TestRunner(t, files)
+		if err := rule.Check(runner); err != nil {
+			t.Fatalf("Unexpected error occurred: %s", err)
+		}
+		helper.AssertIssues(t, helper.Issues{
+			{
+				Rule:    rule,
+				Message: "variable block belongs in variables.tf or variables_*.tf",
+				Range:   hcl.Range{Filename: "modules/x/inputs.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+			},
+		}, runner.Issues)
+	})
+}
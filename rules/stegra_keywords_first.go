@@ -8,10 +8,12 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
-// StegraKeywordsFirstRule enforces that configured attributes appear first within resource/data blocks.
+// StegraKeywordsFirstRule enforces that configured attributes and nested blocks appear first within
+// resource/data/module blocks, with an optional keyword list per block type.
 type StegraKeywordsFirstRule struct{ tflint.DefaultRule }
 
 func NewStegraKeywordsFirstRule() *StegraKeywordsFirstRule { return &StegraKeywordsFirstRule{} }
@@ -23,26 +25,48 @@ func (r *StegraKeywordsFirstRule) Severity() tflint.Severity {
 func (r *StegraKeywordsFirstRule) Link() string { return "" }
 
 type stegraKeywordsFirstConfig struct {
-    Keywords []string `hclext:"keywords,optional"`
+	Keywords         []string `hclext:"keywords,optional"`
+	ResourceKeywords []string `hclext:"resource_keywords,optional"`
+	DataKeywords     []string `hclext:"data_keywords,optional"`
+	ModuleKeywords   []string `hclext:"module_keywords,optional"`
+}
+
+// forKind returns the keyword list for a top-level block type; per-kind lists override `keywords`.
+func (c stegraKeywordsFirstConfig) forKind(kind string) []string {
+	var list []string
+	switch kind {
+	case "resource":
+		list = c.ResourceKeywords
+	case "data":
+		list = c.DataKeywords
+	case "module":
+		list = c.ModuleKeywords
+	}
+	if len(list) == 0 {
+		return c.Keywords
+	}
+	return list
 }
 
 func (r *StegraKeywordsFirstRule) Check(runner tflint.Runner) error {
 	// Decode config
 	cfg := stegraKeywordsFirstConfig{}
 	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
-	if len(cfg.Keywords) == 0 {
+	if len(cfg.Keywords) == 0 && len(cfg.ResourceKeywords) == 0 && len(cfg.DataKeywords) == 0 && len(cfg.ModuleKeywords) == 0 {
 		return fmt.Errorf("stegra_keywords_first: keywords option is required; set it in .tflint.hcl rule \"stegra_keywords_first\"")
 	}
-    target := map[string]struct{}{}
-    for _, k := range cfg.Keywords {
-        target[k] = struct{}{}
-    }
-    // Desired order priority is the order of the `keywords` list
-    desired := cfg.Keywords
-    rank := make(map[string]int, len(desired))
-    for i, k := range desired {
-        rank[k] = i
-    }
+	// Desired order priority is the order of each kind's keyword list. Entries may name
+	// attributes or nested block types (e.g. `dynamic`, `lifecycle`).
+	ranks := map[string]map[string]int{}
+	for _, kind := range []string{"resource", "data", "module"} {
+		rank := map[string]int{}
+		for i, k := range cfg.forKind(kind) {
+			if _, dup := rank[k]; !dup {
+				rank[k] = i
+			}
+		}
+		ranks[kind] = rank
+	}
 
 	path, err := runner.GetModulePath()
 	if err != nil {
@@ -61,176 +85,126 @@ func (r *StegraKeywordsFirstRule) Check(runner tflint.Runner) error {
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		body, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		lf := layout.Get(filename, file)
+		body := lf.Body
+		if body == nil {
 			continue
 		}
 
-		raw := string(file.Bytes)
-		// build line start byte offsets for safe slicing
-		lineStarts := make([]int, 1, len(raw)/16+2)
-		lineStarts[0] = 0
-		for i := 0; i < len(raw); i++ {
-			if raw[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
+		for _, blk := range body.Blocks {
+			rank := ranks[blk.Type]
+			if len(rank) == 0 {
+				continue
 			}
-		}
 
-        for _, blk := range body.Blocks {
-            if blk.Type != "resource" && blk.Type != "data" && blk.Type != "module" {
-                continue
-            }
-
-			// Collect items (attributes and child blocks) in source order
-			type item struct {
-				kind      string // "attr" or "block"
-				name      string // attr name or block type
-				start     hcl.Pos
-				rng       hcl.Range
-				startByte int
-				endByte   int
-			}
-			items := make([]item, 0, len(blk.Body.Attributes)+len(blk.Body.Blocks))
-
-			for name, a := range blk.Body.Attributes {
-				ir := hcl.Range{Filename: filename, Start: a.NameRange.Start, End: a.Expr.Range().End}
-				// Extend end to the start of the next line (so removing includes trailing newline)
-				endLine := ir.End.Line
-				endByte := len(raw)
-				if endLine < len(lineStarts) {
-					endByte = lineStarts[endLine]
+			items := lf.Items(blk.Body)
+			// Canonical order: keyword items by rank, then every other item in source order
+			order := make([]int, 0, len(items))
+			rest := make([]int, 0, len(items))
+			onlyAttrs := true
+			for i, it := range items {
+				if _, ok := rank[it.Name]; ok {
+					order = append(order, i)
+					if it.Kind == layout.Block {
+						onlyAttrs = false
+					}
+				} else {
+					rest = append(rest, i)
 				}
-				items = append(items, item{kind: "attr", name: name, start: a.NameRange.Start, rng: ir, startByte: ir.Start.Byte, endByte: endByte})
 			}
-			for _, cb := range blk.Body.Blocks {
-				sr := cb.TypeRange
-				// End at the start of line after the closing brace
-				closeLine := cb.CloseBraceRange.End.Line
-				endByte := len(raw)
-				if closeLine < len(lineStarts) {
-					endByte = lineStarts[closeLine]
+			if len(order) == 0 {
+				continue
+			}
+			sort.SliceStable(order, func(i, j int) bool { return rank[items[order[i]].Name] < rank[items[order[j]].Name] })
+			expected := make([]string, 0, len(order))
+			for i, idx := range order {
+				// Repeated nested blocks (e.g. several `dynamic` blocks) are listed once
+				if i == 0 || items[order[i-1]].Name != items[idx].Name {
+					expected = append(expected, items[idx].Name)
 				}
-				items = append(items, item{kind: "block", name: cb.Type, start: sr.Start, rng: hcl.Range{Filename: filename, Start: sr.Start, End: sr.End}, startByte: sr.Start.Byte, endByte: endByte})
 			}
+			order = append(order, rest...)
 
-			sort.Slice(items, func(i, j int) bool { return items[i].start.Byte < items[j].start.Byte })
-
-            // Count how many target attributes are present
-            present := 0
-            for _, it := range items {
-                if it.kind == "attr" {
-                    if _, ok := target[it.name]; ok {
-                        present++
-                    }
-                }
-            }
-            if present == 0 {
-                continue
-            }
-
-            // Ensure the first 'present' items are all target attributes, and in desired order
-            seenTargets := 0
-            seenTargetIdxs := make([]int, 0, present)
-            // Compute the overall last target index in items for non-target relocation
-            lastTargetOverallIdx := -1
-            for i := range items {
-                if items[i].kind == "attr" {
-                    if _, ok := target[items[i].name]; ok {
-                        if i > lastTargetOverallIdx {
-                            lastTargetOverallIdx = i
-                        }
-                    }
-                }
-            }
-            for idx, it := range items {
-                if it.kind == "attr" {
-                    if _, ok := target[it.name]; ok {
-                        // Order check among targets using priority
-                        rk, has := rank[it.name]
-                        if !has {
-                            // If no explicit rank, treat as after all ranked items
-                            rk = len(desired)
-                        }
-                        // Find any prior seen target with higher rank (should come after this)
-                        misplaceAt := -1
-                        for _, prevIdx := range seenTargetIdxs {
-                            prevName := items[prevIdx].name
-                            pr, ok := rank[prevName]
-                            if !ok {
-                                pr = len(desired)
-                            }
-                            if pr > rk {
-                                misplaceAt = prevIdx
-                                break
-                            }
-                        }
-                        if misplaceAt >= 0 {
-                            // Move this target before the first previously-seen higher-ranked target
-                            insertBefore := items[misplaceAt].rng
-                            off := it
-                            moveText := raw[off.startByte:off.endByte]
-                            delRange := hcl.Range{Filename: filename, Start: hcl.Pos{Line: off.rng.Start.Line, Column: off.rng.Start.Column, Byte: off.startByte}, End: hcl.Pos{Line: off.rng.End.Line, Column: off.rng.End.Column, Byte: off.endByte}}
-                            if err := runner.EmitIssueWithFix(
-                                r,
-                                fmt.Sprintf("These attributes must appear first in this order: %s", strings.Join(desired, ", ")),
-                                it.rng,
-                                func(fixer tflint.Fixer) error {
-                                    if err := fixer.InsertTextBefore(insertBefore, moveText); err != nil {
-                                        return err
-                                    }
-                                    return fixer.ReplaceText(delRange, "")
-                                },
-                            ); err != nil {
-                                return err
-                            }
-                            break
-                        }
-                        seenTargets++
-                        seenTargetIdxs = append(seenTargetIdxs, idx)
-                        continue
-                    }
-                }
-                if seenTargets < present {
-                    // Non-target item appears before all target attributes are listed
-                    // Build a fix that moves this item after the last target attribute present
-                    off := it // offending item
-					// Compute insertion anchor: before the next item after last target, else before closing brace
-                    var insertBefore hcl.Range
-                    if lastTargetOverallIdx >= 0 {
-                        if lastTargetOverallIdx+1 < len(items) {
-                            insertBefore = items[lastTargetOverallIdx+1].rng
-                        } else {
-                            insertBefore = hcl.Range{Filename: filename, Start: blk.CloseBraceRange.Start, End: blk.CloseBraceRange.End}
-                        }
-                    } else {
-                        // Should not happen because 'present' > 0 guarantees a target; fallback to before '}'
-                        insertBefore = hcl.Range{Filename: filename, Start: blk.CloseBraceRange.Start, End: blk.CloseBraceRange.End}
-                    }
-					// Define the slice we will move
-					moveText := raw[off.startByte:off.endByte]
-					// Prepare range to delete original
-					delRange := hcl.Range{Filename: filename, Start: hcl.Pos{Line: off.rng.Start.Line, Column: off.rng.Start.Column, Byte: off.startByte}, End: hcl.Pos{Line: off.rng.End.Line, Column: off.rng.End.Column, Byte: off.endByte}}
-
-                    if err := runner.EmitIssueWithFix(
-                        r,
-                        fmt.Sprintf("These attributes must appear first in this order: %s", strings.Join(desired, ", ")),
-                        it.rng,
-                        func(fixer tflint.Fixer) error {
-                            // Insert moved text before the insertBefore range
-                            if err := fixer.InsertTextBefore(insertBefore, moveText); err != nil {
-                                return err
-							}
-							// Delete original occurrence
-							return fixer.ReplaceText(delRange, "")
-						},
-					); err != nil {
-						return err
-					}
+			// The first item that is not where the canonical order puts it carries the issue
+			misplaced := -1
+			for pos, idx := range order {
+				if pos != idx {
+					misplaced = pos
 					break
 				}
 			}
+			if misplaced < 0 {
+				continue
+			}
+			issueRange := items[misplaced].Range
+			if items[misplaced].Kind == layout.Block {
+				issueRange = items[misplaced].Block.TypeRange
+			}
+
+			noun := "attributes"
+			if !onlyAttrs {
+				noun = "items"
+			}
+			msg := fmt.Sprintf("These %s must appear first in this order: %s", noun, strings.Join(expected, ", "))
+			rng, text, ok := reorderedItems(lf, blk, items, order, nil)
+			if !ok {
+				if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := runner.EmitIssueWithFix(
+				r,
+				msg,
+				issueRange,
+				func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, text) },
+			); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// reorderedItems returns the range spanning the items of blk, from the first leading comment
+// through the newline ending the last item, and its text with the items rearranged so that
+// position i holds items[order[i]]. Each item moves with its leading comment group; the blank
+// lines between items stay in place. When edit is not nil, it returns the text placed at position
+// pos from the text of the item moved there, e.g. to start that slot with a blank line. It reports
+// false when an item shares a line with a brace, as the body can then not be rewritten line by line.
+func reorderedItems(lf *layout.File, blk *hclsyntax.Block, items []layout.Item, order []int, edit func(pos int, text string) string) (hcl.Range, string, bool) {
+	if len(items) == 0 {
+		return hcl.Range{}, "", false
+	}
+	first, last := items[0], items[len(items)-1]
+	if first.CommentLine <= blk.OpenBraceRange.End.Line || last.EndLine() >= blk.CloseBraceRange.Start.Line {
+		return hcl.Range{}, "", false
+	}
+
+	spans := make([][2]int, len(items))
+	texts := make([]string, len(items))
+	for i, it := range items {
+		spans[i][0], spans[i][1] = lf.ItemLines(it, true)
+		texts[i] = string(lf.Src[spans[i][0]:spans[i][1]])
+	}
+	if edit != nil {
+		for pos, idx := range order {
+			texts[idx] = edit(pos, texts[idx])
+		}
+	}
+	return lf.LinesRange(first.CommentLine, last.EndLine()+1), reorderedSpans(lf.Src, spans, texts, order), true
+}
+
+// reorderedSpans returns src from the start of the first span to the end of the last one, with
+// position i holding texts[order[i]] and the gaps between spans kept in place.
+func reorderedSpans(src []byte, spans [][2]int, texts []string, order []int) string {
+	var sb strings.Builder
+	for pos, idx := range order {
+		sb.WriteString(texts[idx])
+		if pos+1 < len(spans) {
+			sb.Write(src[spans[pos][1]:spans[pos+1][0]])
+		}
+	}
+	return sb.String()
+}
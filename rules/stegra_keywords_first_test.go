@@ -37,7 +37,7 @@ rule "stegra_keywords_first" {
             Expected: helper.Issues{
                 {
                     Rule:    rule,
-                    Message: "These attributes must appear first in this order: provider, for_each, count, source",
+                    Message: "These attributes must appear first in this order: for_each",
                     Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 11}},
                 },
             },
@@ -59,11 +59,25 @@ rule "stegra_keywords_first" {
             Expected: helper.Issues{
                 {
                     Rule:    rule,
-                    Message: "These attributes must appear first in this order: provider, for_each, count, source",
-                    Range:   hcl.Range{Filename: "mod.tf", Start: hcl.Pos{Line: 3, Column: 1}, End: hcl.Pos{Line: 3, Column: 14}},
+                    Message: "These attributes must appear first in this order: for_each, source",
+                    Range:   hcl.Range{Filename: "mod.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 15}},
                 },
             },
         },
+		{
+			Name: "several misplaced items - one issue per block",
+			Files: map[string]string{
+				".tflint.hcl": cfg,
+				"main.tf":     "resource \"aws_vpc\" \"a\" {\nname = \"a\"\ncount = 1\ntags = {}\nprovider = aws.x\n}\n",
+			},
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "These attributes must appear first in this order: provider, count",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 11}},
+				},
+			},
+		},
 		{
 			Name: "no targets present - no issue",
 			Files: map[string]string{
@@ -105,3 +119,159 @@ rule "stegra_keywords_first" {
         "main.tf": "resource \"aws_vpc\" \"a\" {\n  for_each = []\n  name     = \"a\"\n}\n",
     }, runner.Changes())
 }
+
+func Test_StegraKeywordsFirstRule_Fix_CanonicalOrderInOnePass(t *testing.T) {
+	rule := NewStegraKeywordsFirstRule()
+	cfg := `
+rule "stegra_keywords_first" {
+  enabled  = true
+  keywords = ["provider", "for_each", "count", "source"]
+}
+`
+	files := map[string]string{
+		".tflint.hcl": cfg,
+		"main.tf": `resource "aws_vpc" "a" {
+  name = "a"
+  # iterate over regions
+  for_each = var.regions
+
+  tags = {}
+  provider = aws.x
+  # trailing note
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	if len(runner.Issues) != 1 {
+		t.Fatalf("expected a single issue for the block, got %d", len(runner.Issues))
+	}
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_vpc" "a" {
+  provider = aws.x
+  # iterate over regions
+  for_each = var.regions
+
+  name = "a"
+  tags = {}
+  # trailing note
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraKeywordsFirstRule_PerKindKeywords(t *testing.T) {
+	rule := NewStegraKeywordsFirstRule()
+	cfg := `
+rule "stegra_keywords_first" {
+  enabled           = true
+  keywords          = ["for_each", "count"]
+  resource_keywords = ["provider", "count", "for_each", "lifecycle"]
+  module_keywords   = ["source", "version", "providers", "for_each"]
+}
+`
+	cases := []struct {
+		Name     string
+		Files    map[string]string
+		Expected helper.Issues
+	}{
+		{
+			Name: "module list orders source before for_each",
+			Files: map[string]string{
+				".tflint.hcl": cfg,
+				"mod.tf":      "module \"m\" {\nsource = \"./m\"\nversion = \"1.0.0\"\nproviders = {}\nfor_each = {}\n}\n",
+			},
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "module list flags for_each before source",
+			Files: map[string]string{
+				".tflint.hcl": cfg,
+				"mod.tf":      "module \"m\" {\nfor_each = {}\nsource = \"./m\"\n}\n",
+			},
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "These attributes must appear first in this order: source, for_each",
+					Range:   hcl.Range{Filename: "mod.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 14}},
+				},
+			},
+		},
+		{
+			Name: "data falls back to keywords",
+			Files: map[string]string{
+				".tflint.hcl": cfg,
+				"data.tf":     "data \"aws_vpc\" \"a\" {\ncount = 1\nfor_each = {}\n}\n",
+			},
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "These attributes must appear first in this order: for_each, count",
+					Range:   hcl.Range{Filename: "data.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 10}},
+				},
+			},
+		},
+		{
+			Name: "resource list includes nested block type",
+			Files: map[string]string{
+				".tflint.hcl": cfg,
+				"main.tf":     "resource \"aws_vpc\" \"a\" {\ncount = 1\nname = \"a\"\nlifecycle {}\n}\n",
+			},
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "These items must appear first in this order: count, lifecycle",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 1}, End: hcl.Pos{Line: 3, Column: 11}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, tc.Files)
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_StegraKeywordsFirstRule_Fix_MovesNestedBlock(t *testing.T) {
+	rule := NewStegraKeywordsFirstRule()
+	files := map[string]string{
+		".tflint.hcl": `
+rule "stegra_keywords_first" {
+  enabled           = true
+  resource_keywords = ["count", "dynamic"]
+}
+`,
+		"main.tf": `resource "aws_security_group" "a" {
+  name = "a"
+  dynamic "ingress" {
+    for_each = var.rules
+    content {}
+  }
+  count = 1
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "a" {
+  count = 1
+  dynamic "ingress" {
+    for_each = var.rules
+    content {}
+  }
+  name = "a"
+}
+`,
+	}, runner.Changes())
+}
@@ -0,0 +1,315 @@
+package rules
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StegraModuleSourcePinningRule classifies the source of each module call (local, registry, git,
+// https, s3) and applies a pinning policy to each class: git sources pin `ref` to a tag or commit
+// SHA, registry sources set a `version` and local paths stay inside the repository. https and s3
+// sources carry no version of their own and are only checked against `allowed_source_types`.
+type StegraModuleSourcePinningRule struct{ tflint.DefaultRule }
+
+func NewStegraModuleSourcePinningRule() *StegraModuleSourcePinningRule {
+	return &StegraModuleSourcePinningRule{}
+}
+func (r *StegraModuleSourcePinningRule) Name() string              { return "stegra_module_source_pinning" }
+func (r *StegraModuleSourcePinningRule) Enabled() bool             { return true }
+func (r *StegraModuleSourcePinningRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraModuleSourcePinningRule) Link() string              { return "" }
+
+type stegraModuleSourcePinningConfig struct {
+	// VersionPolicy is "exact" (default), "~>" or "any", as in stegra_required_providers_format
+	VersionPolicy string `hclext:"version_policy,optional"`
+	// AllowedSourceTypes limits the source classes; empty allows all of them
+	AllowedSourceTypes        []string `hclext:"allowed_source_types,optional"`
+	AllowedRegistryNamespaces []string `hclext:"allowed_registry_namespaces,optional"`
+	AllowedGitHosts           []string `hclext:"allowed_git_hosts,optional"`
+	TagPattern                string   `hclext:"tag_pattern,optional"`
+	RepositoryRoot            string   `hclext:"repository_root,optional"`
+}
+
+var (
+	defaultTagPattern = `^v?\d+(\.\d+){0,2}(-[0-9A-Za-z.-]+)?$`
+	commitSHAPattern  = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	// registrySourcePattern matches [host/]namespace/name/provider with an optional //subdir
+	registrySourcePattern = regexp.MustCompile(`^(([0-9A-Za-z.-]+\.[0-9A-Za-z.-]+(:\d+)?)/)?([0-9A-Za-z][0-9A-Za-z_-]*)/([0-9A-Za-z][0-9A-Za-z_-]*)/([0-9a-z]+)(//.*)?$`)
+	scpGitPattern         = regexp.MustCompile(`^[A-Za-z0-9._-]+@([A-Za-z0-9.-]+):`)
+)
+
+// moduleSource is a module source string split into what the pinning rules need.
+type moduleSource struct {
+	// class is "local", "registry", "git", "https", "s3" or "other"
+	class     string
+	host      string
+	namespace string
+	// ref is the git `ref` query parameter, hasRef tells an empty ref from a missing one
+	ref    string
+	hasRef bool
+	// normalized identifies the module independent of its version: the `ref` parameter is dropped
+	// and registry hosts and namespaces are lower-cased
+	normalized string
+}
+
+// parseModuleSource classifies src following Terraform's source address detection.
+func parseModuleSource(src string) moduleSource {
+	switch {
+	case strings.HasPrefix(src, "./") || strings.HasPrefix(src, "../") || src == "." || src == "..":
+		return moduleSource{class: "local", normalized: filepath.ToSlash(filepath.Clean(src))}
+	case strings.HasPrefix(src, "git::") || strings.HasPrefix(src, "github.com/") ||
+		strings.HasPrefix(src, "bitbucket.org/") || scpGitPattern.MatchString(src):
+		return parseGitSource(src)
+	case strings.HasPrefix(src, "s3::") || strings.Contains(src, ".amazonaws.com/") && strings.Contains(src, "s3"):
+		return moduleSource{class: "s3", normalized: src}
+	case strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://"):
+		return moduleSource{class: "https", host: urlHost(src), normalized: src}
+	}
+	if m := registrySourcePattern.FindStringSubmatch(src); m != nil {
+		host := strings.ToLower(m[2])
+		if host == "" {
+			host = "registry.terraform.io"
+		}
+		ns := strings.ToLower(m[4])
+		return moduleSource{
+			class:      "registry",
+			host:       host,
+			namespace:  ns,
+			normalized: host + "/" + ns + "/" + strings.ToLower(m[5]) + "/" + strings.ToLower(m[6]) + m[7],
+		}
+	}
+	return moduleSource{class: "other", normalized: src}
+}
+
+// parseGitSource extracts the host and `ref` of a git source and drops `ref` from the
+// normalized form.
+func parseGitSource(src string) moduleSource {
+	ms := moduleSource{class: "git"}
+	addr := strings.TrimPrefix(src, "git::")
+	base, query, _ := strings.Cut(addr, "?")
+	switch {
+	case scpGitPattern.MatchString(base):
+		ms.host = scpGitPattern.FindStringSubmatch(base)[1]
+	case strings.Contains(base, "://"):
+		ms.host = urlHost(base)
+	default:
+		ms.host, _, _ = strings.Cut(base, "/")
+	}
+	ms.host = strings.ToLower(ms.host)
+
+	ms.normalized = base
+	if query == "" {
+		return ms
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		ms.normalized = addr
+		return ms
+	}
+	if refs, ok := values["ref"]; ok {
+		ms.ref, ms.hasRef = refs[0], true
+		values.Del("ref")
+	}
+	if rest := values.Encode(); rest != "" {
+		ms.normalized += "?" + rest
+	}
+	return ms
+}
+
+// urlHost returns the host of a URL without port and user info, or "" if it does not parse.
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func (r *StegraModuleSourcePinningRule) Check(runner tflint.Runner) error {
+	cfg := stegraModuleSourcePinningConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	policy := cfg.VersionPolicy
+	switch policy {
+	case "":
+		policy = "exact"
+	case "exact", "~>", "any":
+	default:
+		return fmt.Errorf("%s: version_policy must be one of \"exact\", \"~>\" or \"any\", got %q", r.Name(), policy)
+	}
+	tagPattern := cfg.TagPattern
+	if tagPattern == "" {
+		tagPattern = defaultTagPattern
+	}
+	tagRe, err := regexp.Compile(tagPattern)
+	if err != nil {
+		return fmt.Errorf("%s: invalid tag_pattern: %s", r.Name(), err)
+	}
+	// Without a configured root, the nearest directory holding .git is the repository root; local
+	// sources are not checked when there is none
+	root := cfg.RepositoryRoot
+	if root == "" {
+		if root, err = findRepositoryRoot(); err != nil {
+			return err
+		}
+	}
+	rootAbs := ""
+	if root != "" {
+		if rootAbs, err = filepath.Abs(root); err != nil {
+			return err
+		}
+	}
+	allowedTypes := map[string]bool{}
+	for _, t := range cfg.AllowedSourceTypes {
+		allowedTypes[t] = true
+	}
+	allowedNamespaces := map[string]bool{}
+	for _, ns := range cfg.AllowedRegistryNamespaces {
+		allowedNamespaces[ns] = true
+	}
+	allowedHosts := map[string]bool{}
+	for _, h := range cfg.AllowedGitHosts {
+		allowedHosts[h] = true
+	}
+
+	body, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{
+				Type:       "module",
+				LabelNames: []string{"name"},
+				Body: &hclext.BodySchema{
+					Attributes: []hclext.AttributeSchema{{Name: "source"}, {Name: "version"}},
+				},
+			},
+		},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return err
+	}
+
+	for _, blk := range body.Blocks {
+		name := blk.Labels[0]
+		srcAttr, ok := blk.Body.Attributes["source"]
+		if !ok {
+			continue
+		}
+		src, ok := staticString(srcAttr.Expr)
+		if !ok {
+			continue
+		}
+		ms := parseModuleSource(src)
+		srcRange := srcAttr.Expr.Range()
+
+		if len(allowedTypes) > 0 && !allowedTypes[ms.class] {
+			if err := runner.EmitIssue(r, fmt.Sprintf("module `%s` source type `%s` is not allowed", name, ms.class), srcRange); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch ms.class {
+		case "local":
+			if rootAbs == "" {
+				continue
+			}
+			target, err := filepath.Abs(filepath.Join(filepath.Dir(srcRange.Filename), src))
+			if err != nil {
+				return err
+			}
+			if rel, err := filepath.Rel(rootAbs, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				msg := fmt.Sprintf("module `%s` local source %q must not escape the repository root", name, src)
+				if err := runner.EmitIssue(r, msg, srcRange); err != nil {
+					return err
+				}
+			}
+
+		case "git":
+			var msg string
+			switch {
+			case len(allowedHosts) > 0 && !allowedHosts[ms.host]:
+				msg = fmt.Sprintf("module `%s` git host `%s` is not allowed", name, ms.host)
+			case !ms.hasRef || ms.ref == "":
+				msg = fmt.Sprintf("module `%s` git source must pin `ref` to a tag or a 40-character commit SHA", name)
+			case !tagRe.MatchString(ms.ref) && !commitSHAPattern.MatchString(ms.ref):
+				msg = fmt.Sprintf("module `%s` git source must pin `ref` to a tag or a 40-character commit SHA, got %q", name, ms.ref)
+			}
+			if msg != "" {
+				if err := runner.EmitIssue(r, msg, srcRange); err != nil {
+					return err
+				}
+			}
+
+		case "registry":
+			if len(allowedNamespaces) > 0 && !allowedNamespaces[ms.namespace] && !allowedNamespaces[ms.host+"/"+ms.namespace] {
+				msg := fmt.Sprintf("module `%s` registry namespace `%s` is not allowed", name, ms.namespace)
+				if err := runner.EmitIssue(r, msg, srcRange); err != nil {
+					return err
+				}
+			}
+			verAttr, ok := blk.Body.Attributes["version"]
+			if !ok {
+				if err := runner.EmitIssue(r, fmt.Sprintf("module `%s` must set `version` for a registry source", name), srcRange); err != nil {
+					return err
+				}
+				continue
+			}
+			constraint, ok := staticString(verAttr.Expr)
+			if !ok {
+				continue
+			}
+			constraint = strings.TrimSpace(constraint)
+			var msg string
+			switch {
+			case policy == "exact" && !exactVersionPattern.MatchString(constraint):
+				msg = fmt.Sprintf("module `%s` must pin an exact version, got %q", name, constraint)
+			case policy == "~>" && !pessimisticVersionPattern.MatchString(constraint):
+				msg = fmt.Sprintf("module `%s` must use a `~>` version constraint, got %q", name, constraint)
+			case constraint == "":
+				msg = fmt.Sprintf("module `%s` must set `version` for a registry source", name)
+			}
+			if msg != "" {
+				if err := runner.EmitIssue(r, msg, verAttr.Expr.Range()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// staticString returns the value of expr if it is a known string without references.
+func staticString(expr hcl.Expression) (string, bool) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		return "", false
+	}
+	return v.AsString(), true
+}
+
+// findRepositoryRoot returns the nearest directory at or above the working directory that holds
+// a .git entry, or "" when there is none.
+func findRepositoryRoot() (string, error) {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,208 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraModuleSourcePinningRule(t *testing.T) {
+	rule := NewStegraModuleSourcePinningRule()
+	files := map[string]string{
+		"main.tf": `module "local_ok" {
+  source = "./modules/net"
+}
+
+module "local_escape" {
+  source = "../shared/net"
+}
+
+module "registry_ok" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+
+module "registry_missing" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+module "registry_range" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.8"
+}
+
+module "git_tag" {
+  source = "git::https://github.com/acme/net.git//vpc?ref=v1.2.0"
+}
+
+module "git_sha" {
+  source = "git@github.com:acme/net.git?ref=0123456789abcdef0123456789abcdef01234567"
+}
+
+module "git_branch" {
+  source = "github.com/acme/net?ref=main"
+}
+
+module "git_unpinned" {
+  source = "git::ssh://git@gitlab.example.com/acme/net.git"
+}
+`,
+		".tflint.hcl": `
+rule "stegra_module_source_pinning" {
+  enabled         = true
+  repository_root = "."
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `local_escape` local source \"../shared/net\" must not escape the repository root",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 6, Column: 12}, End: hcl.Pos{Line: 6, Column: 27}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `registry_missing` must set `version` for a registry source",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 15, Column: 12}, End: hcl.Pos{Line: 15, Column: 43}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `registry_range` must pin an exact version, got \"~> 5.8\"",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 20, Column: 13}, End: hcl.Pos{Line: 20, Column: 21}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `git_branch` git source must pin `ref` to a tag or a 40-character commit SHA, got \"main\"",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 32, Column: 12}, End: hcl.Pos{Line: 32, Column: 42}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `git_unpinned` git source must pin `ref` to a tag or a 40-character commit SHA",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 36, Column: 12}, End: hcl.Pos{Line: 36, Column: 60}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraModuleSourcePinningRule_RepositoryRoot(t *testing.T) {
+	rule := NewStegraModuleSourcePinningRule()
+	files := map[string]string{
+		"environments/prod/main.tf": `module "vpc" {
+  source = "../../modules/vpc"
+}
+
+module "outside" {
+  source = "../../../shared/vpc"
+}
+`,
+	}
+
+	// The root defaults to the enclosing git repository, which holds the test directory and both
+	// targets; a call from a subdirectory is resolved against that directory, not the working one
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+
+	files[".tflint.hcl"] = `
+rule "stegra_module_source_pinning" {
+  enabled         = true
+  repository_root = "."
+}
+`
+	runner = helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `outside` local source \"../../../shared/vpc\" must not escape the repository root",
+			Range:   hcl.Range{Filename: "environments/prod/main.tf", Start: hcl.Pos{Line: 6, Column: 12}, End: hcl.Pos{Line: 6, Column: 33}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraModuleSourcePinningRule_AllowLists(t *testing.T) {
+	rule := NewStegraModuleSourcePinningRule()
+	files := map[string]string{
+		"main.tf": `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.8"
+}
+
+module "internal" {
+  source  = "app.terraform.io/acme/net/aws"
+  version = "5.8.1"
+}
+
+module "net" {
+  source = "git::https://github.com/acme/net.git?ref=v1.2.0"
+}
+
+module "blob" {
+  source = "s3::https://s3-eu-west-1.amazonaws.com/acme/net.zip"
+}
+`,
+		".tflint.hcl": `
+rule "stegra_module_source_pinning" {
+  enabled                     = true
+  version_policy              = "~>"
+  allowed_source_types        = ["local", "registry", "git"]
+  allowed_registry_namespaces = ["app.terraform.io/acme"]
+  allowed_git_hosts           = ["gitlab.example.com"]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `vpc` registry namespace `terraform-aws-modules` is not allowed",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 13}, End: hcl.Pos{Line: 2, Column: 44}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `internal` must use a `~>` version constraint, got \"5.8.1\"",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 8, Column: 13}, End: hcl.Pos{Line: 8, Column: 20}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `net` git host `github.com` is not allowed",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 12, Column: 12}, End: hcl.Pos{Line: 12, Column: 61}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `blob` source type `s3` is not allowed",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 16, Column: 12}, End: hcl.Pos{Line: 16, Column: 65}},
+		},
+	}, runner.Issues)
+}
+
+func Test_parseModuleSource(t *testing.T) {
+	cases := []struct {
+		src                          string
+		class, host, ref, normalized string
+	}{
+		{"./modules/net/", "local", "", "", "modules/net"},
+		{"Terraform-AWS-Modules/vpc/aws//modules/endpoints", "registry", "registry.terraform.io", "", "registry.terraform.io/terraform-aws-modules/vpc/aws//modules/endpoints"},
+		{"git::https://github.com/acme/net.git//vpc?ref=v1.2.0&depth=1", "git", "github.com", "v1.2.0", "https://github.com/acme/net.git//vpc?depth=1"},
+		{"git@github.com:acme/net.git?ref=v1", "git", "github.com", "v1", "git@github.com:acme/net.git"},
+		{"https://example.com/net.zip", "https", "example.com", "", "https://example.com/net.zip"},
+		{"gcs::https://www.googleapis.com/storage/v1/acme/net.zip", "other", "", "", "gcs::https://www.googleapis.com/storage/v1/acme/net.zip"},
+	}
+	for _, c := range cases {
+		ms := parseModuleSource(c.src)
+		if ms.class != c.class || ms.host != c.host || ms.ref != c.ref || ms.normalized != c.normalized {
+			t.Errorf("parseModuleSource(%q) = %+v", c.src, ms)
+		}
+	}
+}
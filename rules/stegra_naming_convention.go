@@ -0,0 +1,256 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StegraNamingConventionRule checks every kind of label (resource, data and module names, variables,
+// outputs, local values and provider aliases) against a format and length limits. Resource, data
+// and module names are fixed by converting them to snake_case and rewriting references.
+type StegraNamingConventionRule struct{ tflint.DefaultRule }
+
+func NewStegraNamingConventionRule() *StegraNamingConventionRule {
+	return &StegraNamingConventionRule{}
+}
+func (r *StegraNamingConventionRule) Name() string              { return "stegra_naming_convention" }
+func (r *StegraNamingConventionRule) Enabled() bool             { return true }
+func (r *StegraNamingConventionRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraNamingConventionRule) Link() string              { return "" }
+
+// namingPolicy is the format and length limits for one label kind. Unset fields inherit the
+// top-level values; a length of 0 means no limit.
+type namingPolicy struct {
+	Format    string `hclext:"format,optional"`
+	MinLength int    `hclext:"min_length,optional"`
+	MaxLength int    `hclext:"max_length,optional"`
+}
+
+type stegraNamingConventionConfig struct {
+	Format        string        `hclext:"format,optional"`
+	MinLength     int           `hclext:"min_length,optional"`
+	MaxLength     int           `hclext:"max_length,optional"`
+	MovedFile     string        `hclext:"moved_file,optional"`
+	Resource      *namingPolicy `hclext:"resource,block"`
+	Data          *namingPolicy `hclext:"data,block"`
+	Module        *namingPolicy `hclext:"module,block"`
+	Variable      *namingPolicy `hclext:"variable,block"`
+	Output        *namingPolicy `hclext:"output,block"`
+	Local         *namingPolicy `hclext:"local,block"`
+	ProviderAlias *namingPolicy `hclext:"provider_alias,block"`
+}
+
+// defaultNamingFormat is snake_case: lower-case words of letters and digits joined by underscores.
+const defaultNamingFormat = `^[a-z][a-z0-9]*(_[a-z0-9]+)*$`
+
+// compiledNamingPolicy is a namingPolicy with the inherited values applied.
+type compiledNamingPolicy struct {
+	format    *regexp.Regexp
+	minLength int
+	maxLength int
+}
+
+// policyFor merges the per-kind block over the top-level values.
+func (c stegraNamingConventionConfig) policyFor(kind *namingPolicy) (compiledNamingPolicy, error) {
+	p := namingPolicy{Format: c.Format, MinLength: c.MinLength, MaxLength: c.MaxLength}
+	if kind != nil {
+		if kind.Format != "" {
+			p.Format = kind.Format
+		}
+		if kind.MinLength != 0 {
+			p.MinLength = kind.MinLength
+		}
+		if kind.MaxLength != 0 {
+			p.MaxLength = kind.MaxLength
+		}
+	}
+	if p.Format == "" {
+		p.Format = defaultNamingFormat
+	}
+	re, err := regexp.Compile(p.Format)
+	if err != nil {
+		return compiledNamingPolicy{}, err
+	}
+	return compiledNamingPolicy{format: re, minLength: p.MinLength, maxLength: p.MaxLength}, nil
+}
+
+// violation describes why name breaks the policy, or returns "".
+func (p compiledNamingPolicy) violation(name string) string {
+	switch {
+	case !p.format.MatchString(name):
+		return fmt.Sprintf("must match %s", p.format.String())
+	case p.minLength > 0 && len(name) < p.minLength:
+		return fmt.Sprintf("must be at least %d characters long", p.minLength)
+	case p.maxLength > 0 && len(name) > p.maxLength:
+		return fmt.Sprintf("must be at most %d characters long", p.maxLength)
+	}
+	return ""
+}
+
+func (r *StegraNamingConventionRule) Check(runner tflint.Runner) error {
+	cfg := stegraNamingConventionConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	policies := map[string]compiledNamingPolicy{}
+	for kind, p := range map[string]*namingPolicy{
+		"resource":       cfg.Resource,
+		"data":           cfg.Data,
+		"module":         cfg.Module,
+		"variable":       cfg.Variable,
+		"output":         cfg.Output,
+		"local":          cfg.Local,
+		"provider_alias": cfg.ProviderAlias,
+	} {
+		compiled, err := cfg.policyFor(p)
+		if err != nil {
+			return fmt.Errorf("%s: invalid format for %s: %s", r.Name(), kind, err)
+		}
+		policies[kind] = compiled
+	}
+
+	body, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{Type: "resource", LabelNames: []string{"type", "name"}, Body: &hclext.BodySchema{}},
+			{Type: "data", LabelNames: []string{"type", "name"}, Body: &hclext.BodySchema{}},
+			{Type: "module", LabelNames: []string{"name"}, Body: &hclext.BodySchema{}},
+		},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return err
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	moved := newMovedBlocks()
+
+	taken := takenAddresses(body)
+
+	byType := body.Blocks.ByType()
+	for _, kind := range []string{"resource", "data", "module"} {
+		policy := policies[kind]
+		for _, blk := range byType[kind] {
+			nameIdx := len(blk.Labels) - 1
+			name := blk.Labels[nameIdx]
+			reason := policy.violation(name)
+			if reason == "" {
+				continue
+			}
+			nameRange := blk.LabelRanges[nameIdx]
+			msg := fmt.Sprintf("%s name `%s` %s", kind, name, reason)
+
+			// Only format problems can be fixed; the snake_case name must satisfy the whole policy
+			newName := toSnakeCase(name)
+			labels := append(append([]string(nil), blk.Labels[:nameIdx]...), newName)
+			_, exists := taken[blockAddress(kind, labels)]
+			if newName == name || !hclsyntax.ValidIdentifier(newName) || policy.violation(newName) != "" || exists {
+				if err := runner.EmitIssue(r, msg, nameRange); err != nil {
+					return err
+				}
+				continue
+			}
+			taken[blockAddress(kind, labels)] = struct{}{}
+
+			fix, _ := renameBlockFix(files, moved, kind, blk.Labels, nameRange, newName, cfg.MovedFile)
+			if err := runner.EmitIssueWithFix(r, msg, nameRange, fix); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Variables, outputs, locals and provider aliases are only reported: renaming them changes
+	// the module's interface or needs more than a reference rewrite
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		for _, blk := range lf.Body.Blocks {
+			switch blk.Type {
+			case "variable", "output":
+				if len(blk.Labels) == 0 {
+					continue
+				}
+				if reason := policies[blk.Type].violation(blk.Labels[0]); reason != "" {
+					msg := fmt.Sprintf("%s name `%s` %s", blk.Type, blk.Labels[0], reason)
+					if err := runner.EmitIssue(r, msg, blk.LabelRanges[0]); err != nil {
+						return err
+					}
+				}
+			case "locals":
+				for _, it := range sortedAttributes(blk.Body) {
+					if reason := policies["local"].violation(it.Name); reason != "" {
+						msg := fmt.Sprintf("local name `%s` %s", it.Name, reason)
+						if err := runner.EmitIssue(r, msg, it.NameRange); err != nil {
+							return err
+						}
+					}
+				}
+			case "provider":
+				attr, ok := blk.Body.Attributes["alias"]
+				if !ok {
+					continue
+				}
+				v, diags := attr.Expr.Value(nil)
+				if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+					continue
+				}
+				if reason := policies["provider_alias"].violation(v.AsString()); reason != "" {
+					msg := fmt.Sprintf("provider alias `%s` %s", v.AsString(), reason)
+					if err := runner.EmitIssue(r, msg, attr.Expr.Range()); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedAttributes returns the attributes of body in source order.
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, a := range body.Attributes {
+		attrs = append(attrs, a)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].NameRange.Start.Byte < attrs[j].NameRange.Start.Byte })
+	return attrs
+}
+
+// toSnakeCase converts camelCase, PascalCase, kebab-case and dotted names to snake_case,
+// keeping acronyms together ("HTTPServer" becomes "http_server").
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, c := range runes {
+		if c == '-' || c == '.' || c == ' ' || c == '_' {
+			sb.WriteRune('_')
+			continue
+		}
+		if unicode.IsUpper(c) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(c))
+	}
+	parts := strings.FieldsFunc(sb.String(), func(c rune) bool { return c == '_' })
+	return strings.Join(parts, "_")
+}
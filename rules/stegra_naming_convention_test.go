@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraNamingConventionRule(t *testing.T) {
+	rule := NewStegraNamingConventionRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "LogsBucket" {}
+
+resource "aws_s3_bucket" "HTTPServer" {}
+
+resource "aws_s3_bucket" "http_server" {}
+
+module "Network-Core" {
+  source = "./net"
+}
+
+output "bucket" {
+  value = [aws_s3_bucket.LogsBucket.id, module.Network-Core.id]
+}
+
+variable "LogLevel" {}
+
+locals {
+  okName = 1
+}
+
+provider "aws" {
+  alias = "eu-west"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	format := "must match ^[a-z][a-z0-9]*(_[a-z0-9]+)*$"
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource name `LogsBucket` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 26}, End: hcl.Pos{Line: 1, Column: 38}},
+		},
+		{
+			Rule:    rule,
+			Message: "resource name `HTTPServer` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 26}, End: hcl.Pos{Line: 3, Column: 38}},
+		},
+		{
+			Rule:    rule,
+			Message: "module name `Network-Core` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 7, Column: 8}, End: hcl.Pos{Line: 7, Column: 22}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable name `LogLevel` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 15, Column: 10}, End: hcl.Pos{Line: 15, Column: 20}},
+		},
+		{
+			Rule:    rule,
+			Message: "local name `okName` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 18, Column: 3}, End: hcl.Pos{Line: 18, Column: 9}},
+		},
+		{
+			Rule:    rule,
+			Message: "provider alias `eu-west` " + format,
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 22, Column: 11}, End: hcl.Pos{Line: 22, Column: 20}},
+		},
+	}, runner.Issues)
+	// HTTPServer is not fixed because aws_s3_bucket.http_server already exists
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "logs_bucket" {}
+
+resource "aws_s3_bucket" "HTTPServer" {}
+
+resource "aws_s3_bucket" "http_server" {}
+
+module "network_core" {
+  source = "./net"
+}
+
+output "bucket" {
+  value = [aws_s3_bucket.logs_bucket.id, module.network_core.id]
+}
+
+variable "LogLevel" {}
+
+locals {
+  okName = 1
+}
+
+provider "aws" {
+  alias = "eu-west"
+}
+
+moved {
+  from = aws_s3_bucket.LogsBucket
+  to   = aws_s3_bucket.logs_bucket
+}
+
+moved {
+  from = module.Network-Core
+  to   = module.network_core
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraNamingConventionRule_Lengths(t *testing.T) {
+	rule := NewStegraNamingConventionRule()
+	files := map[string]string{
+		"main.tf": `variable "a" {}
+
+variable "a_very_long_variable_name" {}
+
+output "ok" {
+  value = 1
+}
+`,
+		".tflint.hcl": `
+rule "stegra_naming_convention" {
+  enabled    = true
+  min_length = 2
+
+  variable {
+    max_length = 10
+  }
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "variable name `a` must be at least 2 characters long",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 10}, End: hcl.Pos{Line: 1, Column: 13}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable name `a_very_long_variable_name` must be at most 10 characters long",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 10}, End: hcl.Pos{Line: 3, Column: 37}},
+		},
+	}, runner.Issues)
+}
+
+func Test_toSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"LogsBucket":   "logs_bucket",
+		"HTTPServer":   "http_server",
+		"Network-Core": "network_core",
+		"web2App":      "web2_app",
+		"already_ok":   "already_ok",
+		"__x__y":       "x_y",
+	}
+	for in, want := range cases {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
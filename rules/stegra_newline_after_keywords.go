@@ -1,13 +1,12 @@
 package rules
 
 import (
-    "fmt"
-    "path/filepath"
-    "sort"
-    "strings"
+	"fmt"
+	"path/filepath"
+	"strings"
 
-	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
@@ -42,9 +41,34 @@ func (r *StegraNewlineAfterKeywordsRule) Link() string {
 	return ""
 }
 
-// stegraNewlineConfig allows configuring which keywords to enforce.
+// stegraNewlineConfig allows configuring which keywords to enforce, which keywords may be
+// grouped together and which block types are checked.
 type stegraNewlineConfig struct {
-	Keywords []string `hclext:"keywords,optional"`
+	Keywords   []string                   `hclext:"keywords,optional"`
+	Groups     []stegraNewlineGroupConfig `hclext:"group,block"`
+	BlockTypes []string                   `hclext:"block_types,optional"`
+	// DefaultGroup keeps the module source/version group next to the configured ones (default true)
+	DefaultGroup *bool `hclext:"default_group,optional"`
+}
+
+// stegraNewlineGroupConfig is one `group` block: keywords that may sit adjacent, optionally only
+// in some block types.
+type stegraNewlineGroupConfig struct {
+	Keywords   []string `hclext:"keywords"`
+	BlockTypes []string `hclext:"block_types,optional"`
+}
+
+// newlineGroup is a set of keywords that may sit adjacent; only the last member of a run needs
+// the blank line. blockTypes limits the group to some block types (nil means all).
+type newlineGroup struct {
+	members    map[string]struct{}
+	blockTypes map[string]struct{}
+}
+
+// defaultNewlineGroups keeps the historical exception: in module blocks, source may be directly
+// followed by version.
+var defaultNewlineGroups = []newlineGroup{
+	{members: map[string]struct{}{"source": {}, "version": {}}, blockTypes: map[string]struct{}{"module": {}}},
 }
 
 // Check scans HCL files and enforces a blank line after target attributes.
@@ -60,6 +84,32 @@ func (r *StegraNewlineAfterKeywordsRule) Check(runner tflint.Runner) error {
 	for _, k := range keys {
 		target[k] = struct{}{}
 	}
+	groups := []newlineGroup{}
+	if cfg.DefaultGroup == nil || *cfg.DefaultGroup {
+		groups = append(groups, defaultNewlineGroups...)
+	}
+	for _, g := range cfg.Groups {
+		group := newlineGroup{members: make(map[string]struct{}, len(g.Keywords))}
+		for _, k := range g.Keywords {
+			group.members[k] = struct{}{}
+		}
+		if len(g.BlockTypes) > 0 {
+			group.blockTypes = make(map[string]struct{}, len(g.BlockTypes))
+			for _, t := range g.BlockTypes {
+				group.blockTypes[t] = struct{}{}
+			}
+		}
+		groups = append(groups, group)
+	}
+	// Without block_types every top-level block is checked; with it, listed types are checked
+	// wherever they appear, including nested blocks such as dynamic.
+	var scope map[string]struct{}
+	if len(cfg.BlockTypes) > 0 {
+		scope = make(map[string]struct{}, len(cfg.BlockTypes))
+		for _, t := range cfg.BlockTypes {
+			scope[t] = struct{}{}
+		}
+	}
 
 	path, err := runner.GetModulePath()
 	if err != nil {
@@ -78,93 +128,52 @@ func (r *StegraNewlineAfterKeywordsRule) Check(runner tflint.Runner) error {
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		body, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		lf := layout.Get(filename, file)
+		body := lf.Body
+		if body == nil {
 			continue
 		}
 
-		content := file.Bytes
-		lines := strings.Split(string(content), "\n")
-
-		for _, blk := range body.Blocks {
-			// Build ordered list of items (attributes and child blocks)
-			type item struct {
-				kind  string // "attr" or "block"
-				name  string
-				start hcl.Pos
-			}
-			items := make([]item, 0, len(blk.Body.Attributes)+len(blk.Body.Blocks))
-			for nm, a := range blk.Body.Attributes {
-				items = append(items, item{kind: "attr", name: nm, start: a.NameRange.Start})
-			}
-			for _, cb := range blk.Body.Blocks {
-				items = append(items, item{kind: "block", name: cb.Type, start: cb.TypeRange.Start})
-			}
-			// sort by start byte
-			sort.Slice(items, func(i, j int) bool { return items[i].start.Byte < items[j].start.Byte })
+		blocks := body.Blocks
+		if scope != nil {
+			blocks = nil
+			walkBodyBlocks(body, func(blk *hclsyntax.Block) {
+				if _, ok := scope[blk.Type]; ok {
+					blocks = append(blocks, blk)
+				}
+			})
+		}
 
-			for name, attr := range blk.Body.Attributes {
-				if _, want := target[name]; !want {
+		for _, blk := range blocks {
+			// Ordered list of items (attributes and child blocks)
+			items := lf.Items(blk.Body)
+			for i := 0; i < len(items); i++ {
+				it := items[i]
+				if it.Kind != layout.Attribute {
 					continue
 				}
-				nameStart := attr.NameRange.Start
-				exprRange := attr.Expr.Range()
-				ar := hcl.Range{Filename: filename, Start: nameStart, End: exprRange.End}
-
-				// Skip enforcement if this attribute is the last item in the block
-				// i.e., no other attribute or child block starts after this attribute ends
-				hasFollowing := false
-				endByte := exprRange.End.Byte
-				for otherName, a2 := range blk.Body.Attributes {
-					if otherName == name {
-						continue
-					}
-					if a2.NameRange.Start.Byte > endByte {
-						hasFollowing = true
-						break
-					}
-				}
-				if !hasFollowing {
-					for _, cb := range blk.Body.Blocks {
-						if cb.TypeRange.Start.Byte > endByte {
-							hasFollowing = true
-							break
-						}
-					}
-				}
-				if !hasFollowing {
+				if _, want := target[it.Name]; !want {
 					continue
 				}
 
-				// Exception: in module blocks, allow source directly followed by version without blank line
-				if blk.Type == "module" && name == "source" {
-					// find this item index in ordered list and the immediate next item
-					idx := -1
-					for i := range items {
-						if items[i].kind == "attr" && items[i].name == name && items[i].start.Byte == nameStart.Byte {
-							idx = i
-							break
-						}
-					}
-					if idx >= 0 && idx+1 < len(items) {
-						next := items[idx+1]
-						if next.kind == "attr" && next.name == "version" {
-							continue
-						}
-					}
+				// Extend over adjacent members of a keyword group; the blank line is due after the last one
+				run := []string{it.Name}
+				for i+1 < len(items) && inNewlineGroup(groups, blk.Type, items[i], items[i+1]) {
+					i++
+					run = append(run, items[i].Name)
 				}
-				endLine := ar.End.Line
-				nextIdx := endLine
-				notBlank := false
-				if nextIdx >= len(lines) {
-					notBlank = true
-				} else if strings.TrimSpace(lines[nextIdx]) != "" {
-					notBlank = true
+				last := items[i]
+
+				// Skip enforcement if the run ends the block
+				if i+1 >= len(items) {
+					continue
 				}
-				if notBlank {
+
+				if !lf.IsBlank(last.EndLine() + 1) {
+					ar := last.Range
 					if err := runner.EmitIssueWithFix(
 						r,
-						fmt.Sprintf("%s must be followed by an empty newline", name),
+						fmt.Sprintf("%s must be followed by an empty newline", strings.Join(run, ", ")),
 						ar,
 						func(fixer tflint.Fixer) error { return fixer.InsertTextAfter(ar, "\n") },
 					); err != nil {
@@ -176,3 +185,23 @@ func (r *StegraNewlineAfterKeywordsRule) Check(runner tflint.Runner) error {
 	}
 	return nil
 }
+
+// inNewlineGroup reports whether two consecutive attributes belong to a common group in scope for blockType.
+func inNewlineGroup(groups []newlineGroup, blockType string, a, b layout.Item) bool {
+	if a.Kind != layout.Attribute || b.Kind != layout.Attribute {
+		return false
+	}
+	for _, g := range groups {
+		if g.blockTypes != nil {
+			if _, ok := g.blockTypes[blockType]; !ok {
+				continue
+			}
+		}
+		_, okA := g.members[a.Name]
+		_, okB := g.members[b.Name]
+		if okA && okB {
+			return true
+		}
+	}
+	return false
+}
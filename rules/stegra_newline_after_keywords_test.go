@@ -166,3 +166,181 @@ func Test_StegraNewlineAfterKeywordsRule_MissingConfig(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func Test_StegraNewlineAfterKeywordsRule_Groups(t *testing.T) {
+	rule := NewStegraNewlineAfterKeywordsRule()
+	cfg := `
+rule "stegra_newline_after_keywords" {
+  enabled     = true
+  keywords    = ["for_each", "count", "source"]
+  block_types = ["resource", "module", "dynamic"]
+
+  group {
+    keywords = ["for_each", "provider"]
+  }
+
+  group {
+    keywords = ["count", "provider"]
+  }
+}
+`
+	cases := []struct {
+		Name     string
+		Content  string
+		Expected helper.Issues
+	}{
+		{
+			Name: "group members stay together with one blank line after",
+			Content: `
+resource "aws_s3_bucket" "b" {
+  for_each = var.buckets
+  provider = aws.eu
+
+  bucket = each.key
+}
+`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "group without trailing blank line",
+			Content: `
+module "m" {
+  source  = "./m"
+  version = "1.0.0"
+  name    = "x"
+}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "source, version must be followed by an empty newline",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 3}, End: hcl.Pos{Line: 4, Column: 20}},
+				},
+			},
+		},
+		{
+			Name: "nested dynamic block in scope",
+			Content: `
+resource "aws_security_group" "sg" {
+  name = "sg"
+
+  dynamic "ingress" {
+    for_each = var.rules
+    content {}
+  }
+}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "for_each must be followed by an empty newline",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 6, Column: 5}, End: hcl.Pos{Line: 6, Column: 25}},
+				},
+			},
+		},
+		{
+			Name: "block type out of scope",
+			Content: `
+data "aws_vpc" "v" {
+  count = 1
+  id    = "x"
+}
+`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{".tflint.hcl": cfg, "main.tf": tc.Content})
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_StegraNewlineAfterKeywordsRule_GroupScopes(t *testing.T) {
+	rule := NewStegraNewlineAfterKeywordsRule()
+	content := `
+module "m" {
+  source   = "./m"
+  version  = "1.0.0"
+  for_each = var.m
+  provider = aws.eu
+
+  name = each.key
+}
+
+resource "aws_s3_bucket" "b" {
+  for_each = var.buckets
+  provider = aws.eu
+
+  bucket = each.key
+}
+`
+	cases := []struct {
+		Name     string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "configured groups keep the default module group",
+			Config: `
+rule "stegra_newline_after_keywords" {
+  enabled  = true
+  keywords = ["for_each", "source"]
+
+  group {
+    keywords    = ["for_each", "provider"]
+    block_types = ["resource"]
+  }
+}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "source, version must be followed by an empty newline",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 3}, End: hcl.Pos{Line: 4, Column: 21}},
+				},
+				{
+					Rule:    rule,
+					Message: "for_each must be followed by an empty newline",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 5, Column: 3}, End: hcl.Pos{Line: 5, Column: 19}},
+				},
+			},
+		},
+		{
+			Name: "default group turned off",
+			Config: `
+rule "stegra_newline_after_keywords" {
+  enabled       = true
+  keywords      = ["for_each", "source"]
+  default_group = false
+
+  group {
+    keywords = ["for_each", "provider"]
+  }
+}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "source must be followed by an empty newline",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 3}, End: hcl.Pos{Line: 3, Column: 19}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{".tflint.hcl": tc.Config, "main.tf": content})
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
@@ -1,30 +1,43 @@
 package rules
 
 import (
-    "path/filepath"
-    "strings"
+	"path/filepath"
+	"strings"
 
-    "github.com/hashicorp/hcl/v2"
-    "github.com/hashicorp/hcl/v2/hclsyntax"
-    "github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
-// StegraNoBlankLinesInRequiredProvidersRule enforces no blank lines anywhere inside terraform.required_providers.
+// StegraNoBlankLinesInRequiredProvidersRule enforces no blank lines anywhere inside terraform.required_providers,
+// or inside the blocks and object/list values selected by the configured paths.
 type StegraNoBlankLinesInRequiredProvidersRule struct{ tflint.DefaultRule }
 
 func NewStegraNoBlankLinesInRequiredProvidersRule() *StegraNoBlankLinesInRequiredProvidersRule {
-    return &StegraNoBlankLinesInRequiredProvidersRule{}
+	return &StegraNoBlankLinesInRequiredProvidersRule{}
 }
 func (r *StegraNoBlankLinesInRequiredProvidersRule) Name() string {
-    return "stegra_no_blank_lines_in_required_providers"
+	return "stegra_no_blank_lines_in_required_providers"
 }
 func (r *StegraNoBlankLinesInRequiredProvidersRule) Enabled() bool { return true }
 func (r *StegraNoBlankLinesInRequiredProvidersRule) Severity() tflint.Severity {
-    return tflint.ERROR
+	return tflint.ERROR
 }
 func (r *StegraNoBlankLinesInRequiredProvidersRule) Link() string { return "" }
 
+type stegraNoBlankLinesInRequiredProvidersConfig struct {
+	Paths []string `hclext:"paths,optional"`
+}
+
 func (r *StegraNoBlankLinesInRequiredProvidersRule) Check(runner tflint.Runner) error {
+	cfg := stegraNoBlankLinesInRequiredProvidersConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	paths := cfg.Paths
+	if len(paths) == 0 {
+		paths = []string{"terraform.required_providers"}
+	}
+
 	files, err := runner.GetFiles()
 	if err != nil {
 		return err
@@ -34,82 +47,104 @@ func (r *StegraNoBlankLinesInRequiredProvidersRule) Check(runner tflint.Runner)
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		root, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		// Heredoc- and string-aware classification so literal content is never touched
+		lines := layout.Get(filename, file)
+		root := lines.Body
+		if root == nil {
 			continue
 		}
-		content := string(file.Bytes)
-		// Build line starts for byte offsets
-		lineStarts := make([]int, 1, len(content)/16+2)
-		lineStarts[0] = 0
-		for i := 0; i < len(content); i++ {
-			if content[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
+
+		// A blank line inside several matched regions is reported once, for the first path
+		claimed := map[int]bool{}
+		for _, p := range paths {
+			for _, region := range compactRegions(root, strings.Split(p, ".")) {
+				// Scan the entire region between the braces for blank lines
+				blanks := []int{}
+				for ln := region[0] + 1; ln < region[1]; ln++ {
+					if lines.IsBlank(ln) && !claimed[ln] {
+						claimed[ln] = true
+						blanks = append(blanks, ln)
+					}
+				}
+				if len(blanks) == 0 {
+					continue
+				}
+				// Remove all blank lines found in this region
+				first := blanks[0]
+				if err := runner.EmitIssueWithFix(
+					r,
+					"no blank lines allowed in "+p,
+					hcl.Range{Filename: filename, Start: hcl.Pos{Line: first, Column: 1}, End: hcl.Pos{Line: first, Column: 1}},
+					func(fixer tflint.Fixer) error {
+						for i := len(blanks) - 1; i >= 0; i-- {
+							ln := blanks[i]
+							rng := lines.LinesRange(ln, ln+1)
+							if err := fixer.ReplaceText(rng, ""); err != nil {
+								return err
+							}
+						}
+						return nil
+					},
+				); err != nil {
+					return err
+				}
 			}
 		}
+	}
+
+	return nil
+}
 
-		for _, tf := range root.Blocks {
-			if tf.Type != "terraform" {
-				continue
+// compactRegions returns the opening and closing lines of everything in body selected by path.
+// Each step names a block type, `*` matching any type. After a labelled block, the next steps
+// match its labels one by one, and a single `*` matches all remaining labels. The last step may
+// instead name an attribute whose value is an object or list constructor, e.g. `resource.*.tags`.
+func compactRegions(body *hclsyntax.Body, path []string) [][2]int {
+	if len(path) == 0 {
+		return nil
+	}
+	regions := [][2]int{}
+	if len(path) == 1 {
+		if attr, ok := body.Attributes[path[0]]; ok {
+			switch e := attr.Expr.(type) {
+			case *hclsyntax.ObjectConsExpr:
+				regions = append(regions, [2]int{e.OpenRange.Start.Line, e.SrcRange.End.Line})
+			case *hclsyntax.TupleConsExpr:
+				regions = append(regions, [2]int{e.OpenRange.Start.Line, e.SrcRange.End.Line})
 			}
-			tfBody := tf.Body
-			// find required_providers child blocks
-			for _, rp := range tfBody.Blocks {
-				if rp.Type != "required_providers" {
-					continue
-				}
-                // Scan entire required_providers body for blank lines
-                startLine := rp.OpenBraceRange.End.Line + 1
-                endLine := rp.CloseBraceRange.Start.Line - 1
-                if startLine > endLine {
-                    continue
-                }
-                blanks := []int{}
-                for ln := startLine; ln <= endLine; ln++ {
-                    if ln >= 1 && ln <= len(lineStarts) {
-                        sb := lineStarts[ln-1]
-                        eb := len(content)
-                        if ln < len(lineStarts) {
-                            eb = lineStarts[ln]
-                        }
-                        text := strings.TrimRight(content[sb:eb], "\n")
-                        if strings.TrimSpace(text) == "" {
-                            blanks = append(blanks, ln)
-                        }
-                    }
-                }
-                if len(blanks) == 0 {
-                    continue
-                }
-                // Remove all blank lines found in this block
-                first := blanks[0]
-                if err := runner.EmitIssueWithFix(
-                    r,
-                    "no blank lines allowed in terraform.required_providers",
-                    hcl.Range{Filename: filename, Start: hcl.Pos{Line: first, Column: 1}, End: hcl.Pos{Line: first, Column: 1}},
-                    func(fixer tflint.Fixer) error {
-                        for i := len(blanks) - 1; i >= 0; i-- {
-                            ln := blanks[i]
-                            start := lineStarts[ln-1]
-                            end := 0
-                            if ln < len(lineStarts) {
-                                end = lineStarts[ln]
-                            } else {
-                                end = rp.CloseBraceRange.Start.Byte
-                            }
-                            rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: ln, Column: 1, Byte: start}, End: hcl.Pos{Line: ln + 1, Column: 1, Byte: end}}
-                            if err := fixer.ReplaceText(rng, ""); err != nil {
-                                return err
-                            }
-                        }
-                        return nil
-                    },
-                ); err != nil {
-                    return err
-                }
-            }
-        }
-    }
+		}
+	}
+	for _, blk := range body.Blocks {
+		if path[0] != "*" && path[0] != blk.Type {
+			continue
+		}
+		rest, ok := matchLabels(blk.Labels, path[1:])
+		if !ok {
+			continue
+		}
+		if len(rest) == 0 {
+			regions = append(regions, [2]int{blk.OpenBraceRange.End.Line, blk.CloseBraceRange.Start.Line})
+			continue
+		}
+		regions = append(regions, compactRegions(blk.Body, rest)...)
+	}
+	return regions
+}
 
-    return nil
+// matchLabels consumes the path steps that match labels and returns the remaining steps.
+func matchLabels(labels, path []string) ([]string, bool) {
+	for i := range labels {
+		if len(path) == 0 {
+			// The path selects the block itself
+			return nil, true
+		}
+		if path[0] == "*" {
+			return path[1:], true
+		}
+		if path[0] != labels[i] {
+			return nil, false
+		}
+		path = path[1:]
+	}
+	return path, true
 }
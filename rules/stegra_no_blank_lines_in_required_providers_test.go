@@ -81,3 +81,95 @@ terraform {
 		"main.tf": "\nterraform {\n  required_providers {\n    aws = {\n      source  = \"hashicorp/aws\"\n      version = \"6.20.0\"\n    }\n    gitlab = {\n      source  = \"gitlabhq/gitlab\"\n      version = \"18.5.0\"\n    }\n    random = {\n      source  = \"hashicorp/random\"\n      version = \"3.7.2\"\n    }\n  }\n}\n",
 	}, runner.Changes())
 }
+
+func Test_StegraNoBlankLinesInRequiredProvidersRule_Paths(t *testing.T) {
+	rule := NewStegraNoBlankLinesInRequiredProvidersRule()
+	files := map[string]string{
+		"main.tf": `locals {
+  a = 1
+
+  script = <<-EOT
+    echo a
+
+    echo b
+  EOT
+}
+
+resource "aws_s3_bucket" "main" {
+  bucket = "x"
+
+  tags = {
+    Name = "x"
+
+    # team
+    Team = "y"
+  }
+}
+
+module "vpc" {
+  source = "./vpc"
+
+  providers = {
+    aws = aws.eu
+
+  }
+}
+`,
+		".tflint.hcl": `
+rule "stegra_no_blank_lines_in_required_providers" {
+  enabled = true
+  paths   = ["locals", "resource.*.tags", "module.*.providers"]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "no blank lines allowed in locals",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 1}, End: hcl.Pos{Line: 3, Column: 1}},
+		},
+		{
+			Rule:    rule,
+			Message: "no blank lines allowed in resource.*.tags",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 16, Column: 1}, End: hcl.Pos{Line: 16, Column: 1}},
+		},
+		{
+			Rule:    rule,
+			Message: "no blank lines allowed in module.*.providers",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 27, Column: 1}, End: hcl.Pos{Line: 27, Column: 1}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `locals {
+  a      = 1
+  script = <<-EOT
+    echo a
+
+    echo b
+  EOT
+}
+
+resource "aws_s3_bucket" "main" {
+  bucket = "x"
+
+  tags = {
+    Name = "x"
+    # team
+    Team = "y"
+  }
+}
+
+module "vpc" {
+  source = "./vpc"
+
+  providers = {
+    aws = aws.eu
+  }
+}
+`,
+	}, runner.Changes())
+}
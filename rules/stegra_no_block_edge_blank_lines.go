@@ -2,10 +2,9 @@ package rules
 
 import (
 	"path/filepath"
-	"strings"
 
-	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
@@ -32,22 +31,12 @@ func (r *StegraNoBlockEdgeBlankLinesRule) Check(runner tflint.Runner) error {
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		body, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		lines := layout.Get(filename, file)
+		body := lines.Body
+		if body == nil {
 			continue
 		}
 
-		raw := string(file.Bytes)
-		lines := strings.Split(raw, "\n")
-		// build line start byte offsets
-		lineStarts := make([]int, 1, len(raw)/16+2)
-		lineStarts[0] = 0
-		for i := 0; i < len(raw); i++ {
-			if raw[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
-			}
-		}
-
 		walkBodyBlocks(body, func(blk *hclsyntax.Block) {
 			// Determine the interior line range of the block
 			openLine := blk.OpenBraceRange.End.Line
@@ -59,55 +48,40 @@ func (r *StegraNoBlockEdgeBlankLinesRule) Check(runner tflint.Runner) error {
 			}
 
 			// Leading edge: blank lines immediately after '{'
-			if isBlankLine(lines, startLine) {
+			if lines.IsBlank(startLine) {
 				// remove run of blank lines from startLine up to first non-blank or endLine+1
 				firstContent := startLine
-				for l := startLine; l <= endLine; l++ {
-					if strings.TrimSpace(lines[l-1]) != "" {
-						break
-					}
-					firstContent = l + 1
+				for firstContent <= endLine && lines.IsBlank(firstContent) {
+					firstContent++
 				}
 				// Build range [startLine, firstContent)
-				if firstContent <= len(lineStarts) {
-					startByte := lineStarts[startLine-1]
-					endByte := lineStarts[firstContent-1]
-					rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: startLine, Column: 1, Byte: startByte}, End: hcl.Pos{Line: firstContent, Column: 1, Byte: endByte}}
-					if err := runner.EmitIssueWithFix(
-						r,
-						"block must not start with a blank line",
-						rng,
-						func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
-					); err != nil {
-						return
-					}
+				rng := lines.LinesRange(startLine, firstContent)
+				if err := runner.EmitIssueWithFix(
+					r,
+					"block must not start with a blank line",
+					rng,
+					func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+				); err != nil {
+					return
 				}
 			}
 
 			// Trailing edge: blank lines immediately before '}'
-			if isBlankLine(lines, endLine) {
+			if lines.IsBlank(endLine) {
 				// find the last non-blank line moving upwards
 				lastContent := endLine
-				for l := endLine; l >= startLine; l-- {
-					if strings.TrimSpace(lines[l-1]) != "" {
-						break
-					}
-					lastContent = l - 1
+				for lastContent >= startLine && lines.IsBlank(lastContent) {
+					lastContent--
 				}
 				// We want to delete from lastContent+1 to closeLine
-				fromLine := lastContent + 1
-				if fromLine-1 < len(lineStarts) && closeLine-1 < len(lineStarts) {
-					startByte := lineStarts[fromLine-1]
-					endByte := lineStarts[closeLine-1]
-					rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: fromLine, Column: 1, Byte: startByte}, End: hcl.Pos{Line: closeLine, Column: 1, Byte: endByte}}
-					if err := runner.EmitIssueWithFix(
-						r,
-						"block must not end with a blank line",
-						rng,
-						func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
-					); err != nil {
-						return
-					}
+				rng := lines.LinesRange(lastContent+1, closeLine)
+				if err := runner.EmitIssueWithFix(
+					r,
+					"block must not end with a blank line",
+					rng,
+					func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+				); err != nil {
+					return
 				}
 			}
 		})
@@ -116,13 +90,6 @@ func (r *StegraNoBlockEdgeBlankLinesRule) Check(runner tflint.Runner) error {
 	return nil
 }
 
-func isBlankLine(lines []string, line int) bool {
-	if line <= 0 || line > len(lines) {
-		return false
-	}
-	return strings.TrimSpace(lines[line-1]) == ""
-}
-
 // walkBodyBlocks recursively visits all blocks within the given body.
 func walkBodyBlocks(b *hclsyntax.Body, fn func(*hclsyntax.Block)) {
 	for _, blk := range b.Blocks {
@@ -86,6 +86,12 @@ func Test_StegraNoBlockEdgeBlankLinesRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:     "blank lines at the end of a heredoc are kept",
+			File:     "heredoc.tf",
+			Content:  "resource \"aws_instance\" \"a\" {\nuser_data = <<EOT\necho hi\n\nEOT\n}\n",
+			Expected: helper.Issues{},
+		},
 	}
 
 	for _, tc := range cases {
@@ -2,9 +2,8 @@ package rules
 
 import (
 	"path/filepath"
-	"strings"
 
-	"github.com/hashicorp/hcl/v2"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
@@ -33,76 +32,51 @@ func (r *StegraNoLeadingTrailingBlankLinesRule) Check(runner tflint.Runner) erro
 		if filepath.Ext(filename) != ".tf" {
 			continue
 		}
-		raw := string(file.Bytes)
-		lines := strings.Split(raw, "\n")
-		// Precompute line starts (byte offsets)
-		lineStarts := make([]int, 1, len(raw)/16+2)
-		lineStarts[0] = 0
-		for i := 0; i < len(raw); i++ {
-			if raw[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
-			}
-		}
+		lines := layout.Get(filename, file)
 
 		// Leading blanks: remove every leading blank line
-		firstNonBlank := -1
-		for i, l := range lines {
-			if strings.TrimSpace(strings.TrimRight(l, "\r")) == "" {
-				// leading blank, emit fix to delete
-				if i+1 < len(lines) {
-					ln := i + 1
-					startByte := lineStarts[i]
-					endByte := lineStarts[i+1]
-					rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: ln, Column: 1, Byte: startByte}, End: hcl.Pos{Line: ln + 1, Column: 1, Byte: endByte}}
-					if err := runner.EmitIssueWithFix(
-						r,
-						"leading blank lines are not allowed",
-						rng,
-						func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
-					); err != nil {
-						return err
-					}
-				}
-			} else {
-				firstNonBlank = i
+		firstNonBlank := 0
+		for ln := 1; ln <= lines.LineCount(); ln++ {
+			if !lines.IsBlank(ln) {
+				firstNonBlank = ln
 				break
 			}
+			// leading blank, emit fix to delete
+			if ln < lines.LineCount() {
+				rng := lines.LinesRange(ln, ln+1)
+				if err := runner.EmitIssueWithFix(
+					r,
+					"leading blank lines are not allowed",
+					rng,
+					func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+				); err != nil {
+					return err
+				}
+			}
 		}
 
 		// Trailing blanks: if any exist after the last non-blank line, remove the entire trailing region in one fix
-		if firstNonBlank == -1 {
+		if firstNonBlank == 0 {
 			// File is entirely blank; nothing more to do
 			continue
 		}
-		lastNonBlank := -1
-		for i := len(lines) - 1; i >= 0; i-- {
-			if strings.TrimSpace(strings.TrimRight(lines[i], "\r")) != "" {
-				lastNonBlank = i
-				break
-			}
+		lastNonBlank := lines.LineCount()
+		for lastNonBlank > 0 && lines.IsBlank(lastNonBlank) {
+			lastNonBlank--
 		}
-		if lastNonBlank >= 0 {
-			// trailingCount counts actual blank lines after the last content line, excluding the final empty split element
-			trailingCount := (len(lines) - 1) - (lastNonBlank + 1)
-			if trailingCount > 0 {
-				// Have trailing blank lines starting at lastNonBlank+1 up to EOF
-				startLine := lastNonBlank + 2 // 1-based start of first trailing blank
-				startIdx := lastNonBlank + 1
-				if startIdx < len(lineStarts) {
-					startByte := lineStarts[startIdx]
-					endByte := len(raw)
-					rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: startLine, Column: 1, Byte: startByte}, End: hcl.Pos{Line: len(lines), Column: 1, Byte: endByte}}
-                    if err := runner.EmitIssueWithFix(
-                        r,
-                        "trailing blank lines are not allowed",
-                        rng,
-                        // Remove the trailing run; the preceding line already ends with a newline
-                        func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
-                    ); err != nil {
-                        return err
-                    }
-                }
-            }
+		// The final line is the empty remainder after the last newline; anything between is a trailing blank line
+		if lastNonBlank+1 < lines.LineCount() {
+			rng := lines.LinesRange(lastNonBlank+1, lines.LineCount())
+			rng.End.Byte = len(file.Bytes)
+			if err := runner.EmitIssueWithFix(
+				r,
+				"trailing blank lines are not allowed",
+				rng,
+				// Remove the trailing run; the preceding line already ends with a newline
+				func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+			); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -2,9 +2,8 @@ package rules
 
 import (
 	"path/filepath"
-	"strings"
 
-	"github.com/hashicorp/hcl/v2"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
@@ -39,45 +38,29 @@ func (r *StegraNoMultipleBlankLinesRule) Check(runner tflint.Runner) error {
 		}
 
 		// Scan line-by-line and flag any consecutive blank lines beyond the first.
-		rawContent := string(file.Bytes)
-		lines := strings.Split(rawContent, "\n")
-		// Pre-compute byte offsets for the start of each line to build precise ranges
-		lineStarts := make([]int, 0, len(lines))
-		lineStarts = append(lineStarts, 0)
-		for i := 0; i < len(rawContent); i++ {
-			if rawContent[i] == '\n' {
-				lineStarts = append(lineStarts, i+1)
-			}
-		}
+		// Blank lines inside heredocs and multi-line strings are literal content and never counted.
+		lines := layout.Get(filename, file)
 
 		blankCount := 0
 		seenNonBlank := false
-		for i, raw := range lines {
-			line := strings.TrimRight(raw, "\r")
-			if strings.TrimSpace(line) == "" { // blank line
+		for ln := 1; ln <= lines.LineCount(); ln++ {
+			if lines.IsBlank(ln) {
 				// Leading blanks handled by a separate rule; skip until first non-blank
 				if !seenNonBlank {
 					continue
 				}
 				blankCount++
-				if blankCount >= 2 {
-					ln := i + 1 // 1-based
-					// Prefer fix: remove one of the redundant blank lines
-					if i+1 < len(lines) {
-						// Remove the current blank line including its newline
-						startByte := lineStarts[i]
-						endByte := lineStarts[i+1]
-						rng := hcl.Range{Filename: filename, Start: hcl.Pos{Line: ln, Column: 1, Byte: startByte}, End: hcl.Pos{Line: ln + 1, Column: 1, Byte: endByte}}
-						if err := runner.EmitIssueWithFix(
-							r,
-							"multiple consecutive blank lines are not allowed",
-							rng,
-							func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
-						); err != nil {
-							return err
-						}
-					} else {
-						// Trailing blanks handled by separate rule; skip
+				// Trailing blanks (the final line) are handled by a separate rule
+				if blankCount >= 2 && ln < lines.LineCount() {
+					// Remove the current blank line including its newline
+					rng := lines.LinesRange(ln, ln+1)
+					if err := runner.EmitIssueWithFix(
+						r,
+						"multiple consecutive blank lines are not allowed",
+						rng,
+						func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, "") },
+					); err != nil {
+						return err
 					}
 				}
 			} else {
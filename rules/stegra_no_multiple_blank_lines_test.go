@@ -62,6 +62,52 @@ resource "aws_vpc" "b" {}
 				},
 			},
 		},
+		{
+			Name: "blank lines inside heredoc are literal content",
+			File: "main.tf",
+			Content: `resource "aws_instance" "a" {
+  user_data = <<-EOT
+    #!/bin/bash
+
+
+    echo hello
+  EOT
+}
+`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "blank lines inside multi-line template string are literal content",
+			File: "main.tf",
+			Content: `locals {
+  greeting = "hello ${
+
+
+  var.name}"
+}
+`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "blank lines after heredoc are still flagged",
+			File: "main.tf",
+			Content: `locals {
+  policy = <<EOT
+
+EOT
+}
+
+
+resource "aws_vpc" "b" {}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "multiple consecutive blank lines are not allowed",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 7, Column: 1}, End: hcl.Pos{Line: 8, Column: 1}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1,7 +1,7 @@
 package rules
 
 import (
-	"path/filepath"
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -10,8 +10,9 @@ import (
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
-// StegraNoThisResourceNameRule forbids using "this" as a resource name.
-// If safe (no references of <type>.this found), it auto-fixes to "main".
+// StegraNoThisResourceNameRule forbids placeholder names such as "this" for resources, data sources
+// and modules. It auto-fixes to the configured replacement (by default "main"), or the first free
+// fallback name, rewrites references and records a moved block for resources and modules.
 type StegraNoThisResourceNameRule struct{ tflint.DefaultRule }
 
 func NewStegraNoThisResourceNameRule() *StegraNoThisResourceNameRule {
@@ -22,11 +23,59 @@ func (r *StegraNoThisResourceNameRule) Enabled() bool             { return true
 func (r *StegraNoThisResourceNameRule) Severity() tflint.Severity { return tflint.ERROR }
 func (r *StegraNoThisResourceNameRule) Link() string              { return "" }
 
+type stegraNoThisResourceNameConfig struct {
+	ForbiddenNames []string          `hclext:"forbidden_names,optional"`
+	Replacement    string            `hclext:"replacement,optional"`
+	Replacements   map[string]string `hclext:"replacements,optional"`
+	FallbackNames  []string          `hclext:"fallback_names,optional"`
+	MovedFile      string            `hclext:"moved_file,optional"`
+}
+
+// replacementFor returns the new name for a forbidden name; per-name replacements win over `replacement`.
+func (c stegraNoThisResourceNameConfig) replacementFor(name string) string {
+	for forbidden, repl := range c.Replacements {
+		if strings.EqualFold(forbidden, name) {
+			return repl
+		}
+	}
+	if c.Replacement != "" {
+		return c.Replacement
+	}
+	return "main"
+}
+
 func (r *StegraNoThisResourceNameRule) Check(runner tflint.Runner) error {
-	// Only need top-level content to enumerate resources
+	cfg := stegraNoThisResourceNameConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	forbidden := map[string]struct{}{}
+	names := cfg.ForbiddenNames
+	if len(names) == 0 {
+		names = []string{"this"}
+	}
+	for _, n := range names {
+		forbidden[strings.ToLower(n)] = struct{}{}
+	}
+	// A replacement that is forbidden itself would only trade one bad name for another
+	defaultReplacement := cfg.Replacement
+	if defaultReplacement == "" {
+		defaultReplacement = "main"
+	}
+	replacements := append([]string{defaultReplacement}, cfg.FallbackNames...)
+	for _, repl := range cfg.Replacements {
+		replacements = append(replacements, repl)
+	}
+	for _, repl := range replacements {
+		if _, ok := forbidden[strings.ToLower(repl)]; ok {
+			return fmt.Errorf("stegra_no_this_resource_name: replacement %q is itself in forbidden_names", repl)
+		}
+	}
+
+	// Only need top-level content to enumerate resources, data sources and modules
 	body, err := runner.GetModuleContent(&hclext.BodySchema{
 		Blocks: []hclext.BlockSchema{
 			{Type: "resource", LabelNames: []string{"type", "name"}, Body: &hclext.BodySchema{}},
+			{Type: "data", LabelNames: []string{"type", "name"}, Body: &hclext.BodySchema{}},
+			{Type: "module", LabelNames: []string{"name"}, Body: &hclext.BodySchema{}},
 		},
 	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
 	if err != nil {
@@ -38,108 +87,60 @@ func (r *StegraNoThisResourceNameRule) Check(runner tflint.Runner) error {
 	if err != nil {
 		return err
 	}
-	type refHit struct {
-		file      string
-		startByte int
-		endByte   int
-	}
 
-	for _, blk := range body.Blocks {
-		typ := blk.Labels[0]
-		name := blk.Labels[1]
-		if strings.ToLower(name) != "this" {
-			continue
-		}
+	moved := newMovedBlocks()
 
-		// Collect AST-based reference hits for <type>.this traversals in all .tf files of this module
-		hits := []refHit{}
-		for fname, f := range files {
-			if filepath.Ext(fname) != ".tf" {
+	taken := takenAddresses(body)
+
+	byType := body.Blocks.ByType()
+	for _, kind := range []string{"resource", "data", "module"} {
+		for _, blk := range byType[kind] {
+			// The name is the last label: <type>.<name> for resources and data sources, module.<name> for modules
+			nameIdx := len(blk.Labels) - 1
+			name := blk.Labels[nameIdx]
+			if _, ok := forbidden[strings.ToLower(name)]; !ok {
 				continue
 			}
-			if b, ok := f.Body.(*hclsyntax.Body); ok {
-				content := string(f.Bytes)
-				// Walk expressions in this file to find traversal exprs of form <type>.this
-				hclsyntax.Walk(b, &walkCollector{
-					wantType: typ,
-					filename: fname,
-					content:  content,
-					onHit: func(startByte, endByte int) {
-						hits = append(hits, refHit{file: fname, startByte: startByte, endByte: endByte})
-					},
-				})
-			}
-		}
+			nameRange := hcl.Range{Filename: blk.LabelRanges[nameIdx].Filename, Start: blk.LabelRanges[nameIdx].Start, End: blk.LabelRanges[nameIdx].End}
 
-		// Build range for the name label (second label)
-		nameRange := blk.LabelRanges[1]
-		// Auto-fix when safe or we can update all references as well
-		if err := runner.EmitIssueWithFix(
-			r,
-			func() string {
-				if len(hits) > 0 {
-					return "resource name must not be 'this' (renamed to 'main' and updated references)"
+			// The replacement is used unless its address exists; then the fallback names are tried in order
+			candidates := append([]string{cfg.replacementFor(name)}, cfg.FallbackNames...)
+			newName := ""
+			clashes := []string{}
+			for _, c := range candidates {
+				if !hclsyntax.ValidIdentifier(c) {
+					return fmt.Errorf("stegra_no_this_resource_name: replacement %q for %q is not a valid name", c, name)
 				}
-				return "resource name must not be 'this' (renamed to 'main')"
-			}(),
-			hcl.Range{Filename: nameRange.Filename, Start: nameRange.Start, End: nameRange.End},
-			func(fixer tflint.Fixer) error {
-				// Rename label to "main"
-				if err := fixer.ReplaceText(hcl.Range{Filename: nameRange.Filename, Start: nameRange.Start, End: nameRange.End}, "\"main\""); err != nil {
-					return err
+				labels := append(append([]string(nil), blk.Labels[:nameIdx]...), c)
+				if _, exists := taken[blockAddress(kind, labels)]; exists {
+					clashes = append(clashes, "`"+referenceAddress(kind, labels)+"`")
+					continue
 				}
-				for _, h := range hits {
-					rng := hcl.Range{Filename: h.file, Start: hcl.Pos{Byte: h.startByte}, End: hcl.Pos{Byte: h.endByte}}
-					if err := fixer.ReplaceText(rng, ".main"); err != nil {
-						return err
-					}
+				newName = c
+				taken[blockAddress(kind, labels)] = struct{}{}
+				break
+			}
+			if newName == "" {
+				verb := "exists"
+				if len(clashes) > 1 {
+					verb = "exist"
 				}
-				return nil
-			},
-		); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// walkCollector implements hclsyntax.Visitor to collect traversal refs of form <type>.this
-type walkCollector struct {
-	wantType string
-	filename string
-	content  string
-	onHit    func(startByte int, endByte int)
-}
-
-func (w *walkCollector) Enter(node hclsyntax.Node) hcl.Diagnostics {
-	var tr hcl.Traversal
-	var rng hcl.Range
-	switch e := node.(type) {
-	case *hclsyntax.ScopeTraversalExpr:
-		tr = e.Traversal
-		rng = e.Range()
-	case *hclsyntax.RelativeTraversalExpr:
-		tr = e.Traversal
-		rng = e.Range()
-	default:
-		return nil
-	}
-	if len(tr) >= 2 {
-		if root, ok := tr[0].(hcl.TraverseRoot); ok && root.Name == w.wantType {
-			if attr, ok := tr[1].(hcl.TraverseAttr); ok && attr.Name == "this" {
-				if rng.Start.Byte >= 0 && rng.End.Byte <= len(w.content) {
-					segment := w.content[rng.Start.Byte:rng.End.Byte]
-					needle := w.wantType + ".this"
-					if idx := strings.Index(segment, needle); idx >= 0 {
-						start := rng.Start.Byte + idx + len(w.wantType)
-						end := start + len(".this")
-						w.onHit(start, end)
-					}
+				msg := fmt.Sprintf("%s name must not be '%s' (not fixed: %s already %s)", kind, name, strings.Join(clashes, ", "), verb)
+				if err := runner.EmitIssue(r, msg, nameRange); err != nil {
+					return err
 				}
+				continue
+			}
+
+			fix, referenced := renameBlockFix(files, moved, kind, blk.Labels, nameRange, newName, cfg.MovedFile)
+			msg := fmt.Sprintf("%s name must not be '%s' (renamed to '%s')", kind, name, newName)
+			if referenced {
+				msg = fmt.Sprintf("%s name must not be '%s' (renamed to '%s' and updated references)", kind, name, newName)
+			}
+			if err := runner.EmitIssueWithFix(r, msg, nameRange, fix); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
-
-func (w *walkCollector) Exit(node hclsyntax.Node) hcl.Diagnostics { return nil }
@@ -28,7 +28,7 @@ resource "aws_iam_role" "r" {
 		},
 	}, runner.Issues)
 	helper.AssertChanges(t, map[string]string{
-		"main.tf": "resource \"aws_s3_bucket\" \"main\" {}\nresource \"aws_iam_role\" \"r\" {\n  name = aws_s3_bucket.main.id\n}\n",
+		"main.tf": "resource \"aws_s3_bucket\" \"main\" {}\nresource \"aws_iam_role\" \"r\" {\n  name = aws_s3_bucket.main.id\n}\n\nmoved {\n  from = aws_s3_bucket.this\n  to   = aws_s3_bucket.main\n}\n",
 	}, runner.Changes())
 }
 
@@ -50,6 +50,209 @@ func Test_StegraNoThisResourceNameRule_FixWhenNotReferenced(t *testing.T) {
 		},
 	}, runner.Issues)
 	helper.AssertChanges(t, map[string]string{
-		"main.tf": "resource \"aws_s3_bucket\" \"main\" {}\n",
+		"main.tf": "resource \"aws_s3_bucket\" \"main\" {}\n\nmoved {\n  from = aws_s3_bucket.this\n  to   = aws_s3_bucket.main\n}\n",
+	}, runner.Changes())
+}
+
+func Test_StegraNoThisResourceNameRule_DataAndModuleReferences(t *testing.T) {
+	rule := NewStegraNoThisResourceNameRule()
+	files := map[string]string{
+		"main.tf": `data "aws_caller_identity" "this" {}
+module "this" {
+  source = "./mod"
+}
+output "id" {
+  value = [data.aws_caller_identity.this.account_id, module.this.id]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "data name must not be 'this' (renamed to 'main' and updated references)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 28}, End: hcl.Pos{Line: 1, Column: 34}},
+		},
+		{
+			Rule:    rule,
+			Message: "module name must not be 'this' (renamed to 'main' and updated references)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 8}, End: hcl.Pos{Line: 2, Column: 14}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `data "aws_caller_identity" "main" {}
+module "main" {
+  source = "./mod"
+}
+output "id" {
+  value = [data.aws_caller_identity.main.account_id, module.main.id]
+}
+
+moved {
+  from = module.this
+  to   = module.main
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraNoThisResourceNameRule_ConfiguredNames(t *testing.T) {
+	rule := NewStegraNoThisResourceNameRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_vpc" "default" {}
+resource "aws_subnet" "example" {
+  vpc_id = aws_vpc.default.id
+}
+resource "aws_s3_bucket" "this" {}
+`,
+		".tflint.hcl": `
+rule "stegra_no_this_resource_name" {
+  enabled         = true
+  forbidden_names = ["default", "example"]
+  replacement     = "primary"
+  replacements    = { default = "shared" }
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource name must not be 'default' (renamed to 'shared' and updated references)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 20}, End: hcl.Pos{Line: 1, Column: 29}},
+		},
+		{
+			Rule:    rule,
+			Message: "resource name must not be 'example' (renamed to 'primary')",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 23}, End: hcl.Pos{Line: 2, Column: 32}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_vpc" "shared" {}
+resource "aws_subnet" "primary" {
+  vpc_id = aws_vpc.shared.id
+}
+resource "aws_s3_bucket" "this" {}
+
+moved {
+  from = aws_vpc.default
+  to   = aws_vpc.shared
+}
+
+moved {
+  from = aws_subnet.example
+  to   = aws_subnet.primary
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraNoThisResourceNameRule_ForbiddenReplacement(t *testing.T) {
+	rule := NewStegraNoThisResourceNameRule()
+	cases := []struct {
+		Name   string
+		Config string
+		Error  string
+	}{
+		{
+			Name: "replacement",
+			Config: `
+rule "stegra_no_this_resource_name" {
+  enabled         = true
+  forbidden_names = ["this", "main"]
+}
+`,
+			Error: `stegra_no_this_resource_name: replacement "main" is itself in forbidden_names`,
+		},
+		{
+			Name: "per-name replacement",
+			Config: `
+rule "stegra_no_this_resource_name" {
+  enabled         = true
+  forbidden_names = ["this", "default"]
+  replacements    = { this = "Default" }
+}
+`,
+			Error: `stegra_no_this_resource_name: replacement "Default" is itself in forbidden_names`,
+		},
+		{
+			Name: "fallback name",
+			Config: `
+rule "stegra_no_this_resource_name" {
+  enabled         = true
+  forbidden_names = ["this", "example"]
+  fallback_names  = ["primary", "example"]
+}
+`,
+			Error: `stegra_no_this_resource_name: replacement "example" is itself in forbidden_names`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				".tflint.hcl": tc.Config,
+				"main.tf":     `resource "aws_vpc" "this" {}` + "\n",
+			})
+			err := rule.Check(runner)
+			if err == nil || err.Error() != tc.Error {
+				t.Fatalf("expected error %q, got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func Test_StegraNoThisResourceNameRule_Collisions(t *testing.T) {
+	rule := NewStegraNoThisResourceNameRule()
+	files := map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "this" {}
+resource "aws_vpc" "this" {}
+`,
+		"other.tf": `resource "aws_s3_bucket" "main" {}
+resource "aws_vpc" "main" {}
+resource "aws_vpc" "primary" {}
+`,
+		"moved.tf": `# State moves
+`,
+		".tflint.hcl": `
+rule "stegra_no_this_resource_name" {
+  enabled        = true
+  fallback_names = ["primary"]
+  moved_file     = "moved.tf"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource name must not be 'this' (renamed to 'primary')",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 26}, End: hcl.Pos{Line: 1, Column: 32}},
+		},
+		{
+			Rule:    rule,
+			Message: "resource name must not be 'this' (not fixed: `aws_vpc.main`, `aws_vpc.primary` already exist)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 20}, End: hcl.Pos{Line: 2, Column: 26}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "primary" {}
+resource "aws_vpc" "this" {}
+`,
+		"moved.tf": `# State moves
+
+moved {
+  from = aws_s3_bucket.this
+  to   = aws_s3_bucket.primary
+}
+`,
 	}, runner.Changes())
 }
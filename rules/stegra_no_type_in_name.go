@@ -1,14 +1,18 @@
 package rules
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
 // StegraNoTypeInNameRule prevents repeating the type tokens in the name of resources and data sources.
+// With `fix = true` it strips the repeated tokens, rewrites references and records a moved block.
 type StegraNoTypeInNameRule struct{ tflint.DefaultRule }
 
 func NewStegraNoTypeInNameRule() *StegraNoTypeInNameRule    { return &StegraNoTypeInNameRule{} }
@@ -17,7 +21,160 @@ func (r *StegraNoTypeInNameRule) Enabled() bool             { return true }
 func (r *StegraNoTypeInNameRule) Severity() tflint.Severity { return tflint.ERROR }
 func (r *StegraNoTypeInNameRule) Link() string              { return "" }
 
+type stegraNoTypeInNameConfig struct {
+	Fix                  bool     `hclext:"fix,optional"`
+	AllowedTokens        []string `hclext:"allowed_tokens,optional"`
+	ProviderPrefixLength int      `hclext:"provider_prefix_length,optional"`
+	ProviderPrefixes     []string `hclext:"provider_prefixes,optional"`
+	MatchPlural          bool     `hclext:"match_plural,optional"`
+	FullSuffixOnly       bool     `hclext:"full_suffix_only,optional"`
+}
+
+// typeTokenMatcher finds the tokens of a type that a name repeats.
+type typeTokenMatcher struct {
+	allowed        map[string]struct{}
+	prefixLength   int
+	prefixes       []string
+	matchPlural    bool
+	fullSuffixOnly bool
+}
+
+func newTypeTokenMatcher(cfg stegraNoTypeInNameConfig) *typeTokenMatcher {
+	m := &typeTokenMatcher{
+		allowed:        map[string]struct{}{},
+		prefixLength:   cfg.ProviderPrefixLength,
+		matchPlural:    cfg.MatchPlural,
+		fullSuffixOnly: cfg.FullSuffixOnly,
+	}
+	allowed := cfg.AllowedTokens
+	if len(allowed) == 0 {
+		// Allow "main" to be present in both type and name without flagging
+		allowed = []string{"main"}
+	}
+	for _, t := range allowed {
+		m.allowed[strings.ToLower(t)] = struct{}{}
+	}
+	if m.prefixLength <= 0 {
+		m.prefixLength = 1
+	}
+	for _, p := range cfg.ProviderPrefixes {
+		m.prefixes = append(m.prefixes, strings.TrimSuffix(strings.ToLower(p), "_")+"_")
+	}
+	// Longest known prefix wins (e.g. "google_beta_" before "google_")
+	sort.Slice(m.prefixes, func(i, j int) bool { return len(m.prefixes[i]) > len(m.prefixes[j]) })
+	return m
+}
+
+// suffixTokens returns the tokens of the type after its provider prefix. A known prefix from
+// the table is preferred; otherwise the configured number of leading tokens is dropped.
+func (m *typeTokenMatcher) suffixTokens(typ string) []string {
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(typ, p) && len(typ) > len(p) {
+			return strings.Split(typ[len(p):], "_")
+		}
+	}
+	tokens := strings.Split(typ, "_")
+	if len(tokens) > m.prefixLength {
+		return tokens[m.prefixLength:]
+	}
+	if len(tokens) > 1 {
+		return tokens[1:]
+	}
+	return tokens
+}
+
+// norm returns the form used to compare tokens, singular when plural matching is on.
+func (m *typeTokenMatcher) norm(token string) string {
+	token = strings.ToLower(token)
+	if !m.matchPlural {
+		return token
+	}
+	switch {
+	case strings.HasSuffix(token, "ies") && len(token) > 3:
+		return token[:len(token)-3] + "y"
+	case strings.HasSuffix(token, "sses"), strings.HasSuffix(token, "ches"), strings.HasSuffix(token, "shes"), strings.HasSuffix(token, "xes"):
+		return token[:len(token)-2]
+	case strings.HasSuffix(token, "s") && !strings.HasSuffix(token, "ss") && len(token) > 1:
+		return token[:len(token)-1]
+	}
+	return token
+}
+
+// repeated returns the type tokens that the name repeats, in type order.
+func (m *typeTokenMatcher) repeated(typ, name string) []string {
+	typeTokens := m.suffixTokens(strings.ToLower(typ))
+	nameTokens := []string{}
+	for _, nt := range strings.Split(strings.ToLower(name), "_") {
+		if nt != "" {
+			nameTokens = append(nameTokens, m.norm(nt))
+		}
+	}
+
+	if m.fullSuffixOnly {
+		// Only a contiguous repeat of the whole type suffix counts
+		if start, _ := m.suffixRun(typeTokens, nameTokens); start < 0 {
+			return nil
+		}
+		repeated := []string{}
+		for _, tt := range typeTokens {
+			if _, ok := m.allowed[tt]; tt != "" && !ok {
+				repeated = append(repeated, tt)
+			}
+		}
+		return repeated
+	}
+
+	inName := make(map[string]struct{}, len(nameTokens))
+	for _, nt := range nameTokens {
+		inName[nt] = struct{}{}
+	}
+	repeated := make([]string, 0, len(typeTokens))
+	for _, tt := range typeTokens {
+		if tt == "" {
+			continue
+		}
+		if _, ok := m.allowed[tt]; ok {
+			continue
+		}
+		if _, ok := inName[m.norm(tt)]; ok {
+			repeated = append(repeated, tt)
+		}
+	}
+	return repeated
+}
+
+// suffixRun returns the bounds [start, end) of the first run of nameTokens (in norm form) that
+// repeats the whole type suffix, or -1, -1 when there is none.
+func (m *typeTokenMatcher) suffixRun(typeTokens, nameTokens []string) (int, int) {
+	want := make([]string, 0, len(typeTokens))
+	for _, tt := range typeTokens {
+		if tt != "" {
+			want = append(want, m.norm(tt))
+		}
+	}
+	if len(want) == 0 {
+		return -1, -1
+	}
+	for i := 0; i+len(want) <= len(nameTokens); i++ {
+		match := true
+		for j := range want {
+			if nameTokens[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i, i + len(want)
+		}
+	}
+	return -1, -1
+}
+
 func (r *StegraNoTypeInNameRule) Check(runner tflint.Runner) error {
+	cfg := stegraNoTypeInNameConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	matcher := newTypeTokenMatcher(cfg)
+
 	path, err := runner.GetModulePath()
 	if err != nil {
 		return err
@@ -37,52 +194,99 @@ func (r *StegraNoTypeInNameRule) Check(runner tflint.Runner) error {
 		return err
 	}
 
+	var files map[string]*hcl.File
+	moved := newMovedBlocks()
+	taken := takenAddresses(body)
+	if cfg.Fix {
+		if files, err = runner.GetFiles(); err != nil {
+			return err
+		}
+	}
+
 	byType := body.Blocks.ByType()
 	for _, kind := range []string{"resource", "data"} {
 		for _, block := range byType[kind] {
-			typ := strings.ToLower(block.Labels[0])
-			name := strings.ToLower(block.Labels[1])
-
-			typeTokens := strings.Split(typ, "_")
-			if len(typeTokens) > 1 {
-				typeTokens = typeTokens[1:]
+			repeated := matcher.repeated(block.Labels[0], block.Labels[1])
+			if len(repeated) == 0 {
+				continue
 			}
 
-			nameTokens := map[string]struct{}{}
-			for _, nt := range strings.Split(name, "_") {
-				if nt == "" {
-					continue
+			// Highlight only the name label range (second label)
+			issueRange := hcl.Range{Filename: block.LabelRanges[1].Filename, Start: block.LabelRanges[1].Start, End: block.LabelRanges[1].End}
+			msg := kind + " name `" + block.Labels[1] + "` must not repeat type tokens (" + strings.Join(repeated, ", ") + ")"
+			if !cfg.Fix {
+				if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+					return err
 				}
-				nameTokens[nt] = struct{}{}
+				continue
 			}
 
-			repeated := make([]string, 0, len(typeTokens))
-			for _, tt := range typeTokens {
-				if tt == "" {
-					continue
-				}
-				// Allow "main" to be present in both type and name without flagging
-				if tt == "main" {
-					continue
-				}
-				if _, ok := nameTokens[tt]; ok {
-					repeated = append(repeated, tt)
+			newName, reason := matcher.stripped(block.Labels[0], block.Labels[1], repeated)
+			labels := []string{block.Labels[0], newName}
+			if reason == "" {
+				if _, exists := taken[blockAddress(kind, labels)]; exists {
+					reason = fmt.Sprintf("`%s` already exists", referenceAddress(kind, labels))
 				}
 			}
-
-			if len(repeated) > 0 {
-				// Highlight only the name label range (second label)
-				issueRange := hcl.Range{Filename: block.LabelRanges[1].Filename, Start: block.LabelRanges[1].Start, End: block.LabelRanges[1].End}
-				if err := runner.EmitIssue(
-					r,
-					kind+" name `"+block.Labels[1]+"` must not repeat type tokens ("+strings.Join(repeated, ", ")+")",
-					issueRange,
-				); err != nil {
+			if reason != "" {
+				if err := runner.EmitIssue(r, msg+"; not fixed: "+reason, issueRange); err != nil {
 					return err
 				}
+				continue
+			}
+			taken[blockAddress(kind, labels)] = struct{}{}
+
+			fix, _ := renameBlockFix(files, moved, kind, block.Labels, issueRange, newName, "")
+			if err := runner.EmitIssueWithFix(r, msg, issueRange, fix); err != nil {
+				return err
 			}
 		}
 	}
 
 	return nil
 }
+
+// stripped drops the repeated type tokens from name, comparing tokens in their norm form. With
+// fullSuffixOnly only the contiguous run that repeats the type suffix is dropped, so a standalone
+// token elsewhere in the name stays. It returns a reason instead when the result would be empty or
+// not a valid identifier.
+func (m *typeTokenMatcher) stripped(typ, name string, repeated []string) (string, string) {
+	tokens := []string{}
+	for _, nt := range strings.Split(name, "_") {
+		if nt != "" {
+			tokens = append(tokens, nt)
+		}
+	}
+	norms := make([]string, len(tokens))
+	for i, nt := range tokens {
+		norms[i] = m.norm(nt)
+	}
+	dropped := make(map[string]struct{}, len(repeated))
+	for _, t := range repeated {
+		dropped[m.norm(t)] = struct{}{}
+	}
+	start, end := 0, len(tokens)
+	if m.fullSuffixOnly {
+		if start, end = m.suffixRun(m.suffixTokens(strings.ToLower(typ)), norms); start < 0 {
+			start, end = 0, 0
+		}
+	}
+	drop := make([]bool, len(tokens))
+	for i := start; i < end; i++ {
+		_, drop[i] = dropped[norms[i]]
+	}
+	kept := []string{}
+	for i, nt := range tokens {
+		if !drop[i] {
+			kept = append(kept, nt)
+		}
+	}
+	if len(kept) == 0 {
+		return "", "name would be empty after removing type tokens"
+	}
+	stripped := strings.Join(kept, "_")
+	if !hclsyntax.ValidIdentifier(stripped) {
+		return "", fmt.Sprintf("`%s` is not a valid name", stripped)
+	}
+	return stripped, ""
+}
@@ -93,3 +93,231 @@ resource "aws_main_route_table_association" "main" {}
 		})
 	}
 }
+
+func Test_StegraNoTypeInNameRule_Fix(t *testing.T) {
+	rule := NewStegraNoTypeInNameRule()
+	cfg := `
+rule "stegra_no_type_in_name" {
+  enabled = true
+  fix     = true
+}
+`
+	files := map[string]string{
+		".tflint.hcl": cfg,
+		"main.tf": `resource "aws_security_group" "web_security_group" {}
+
+data "aws_vpc" "main_vpc" {}
+`,
+		"rules.tf": `resource "aws_security_group_rule" "ingress" {
+  security_group_id = aws_security_group.web_security_group.id
+  vpc_id            = data.aws_vpc.main_vpc.id
+  description       = "aws_security_group.web_security_group"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource name `web_security_group` must not repeat type tokens (security, group)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 31}, End: hcl.Pos{Line: 1, Column: 51}},
+		},
+		{
+			Rule:    rule,
+			Message: "data name `main_vpc` must not repeat type tokens (vpc)",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 16}, End: hcl.Pos{Line: 3, Column: 26}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {}
+
+data "aws_vpc" "main" {}
+
+moved {
+  from = aws_security_group.web_security_group
+  to   = aws_security_group.web
+}
+`,
+		"rules.tf": `resource "aws_security_group_rule" "ingress" {
+  security_group_id = aws_security_group.web.id
+  vpc_id            = data.aws_vpc.main.id
+  description       = "aws_security_group.web_security_group"
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraNoTypeInNameRule_FixSeveralMovedBlocks(t *testing.T) {
+	rule := NewStegraNoTypeInNameRule()
+	cfg := `
+rule "stegra_no_type_in_name" {
+  enabled = true
+  fix     = true
+}
+`
+	files := map[string]string{
+		".tflint.hcl": cfg,
+		// No trailing newline: only the first moved block needs the extra separator
+		"main.tf": `resource "aws_security_group" "web_security_group" {}
+
+resource "aws_vpc" "main_vpc" {}`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "web" {}
+
+resource "aws_vpc" "main" {}
+
+moved {
+  from = aws_security_group.web_security_group
+  to   = aws_security_group.web
+}
+
+moved {
+  from = aws_vpc.main_vpc
+  to   = aws_vpc.main
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraNoTypeInNameRule_FixRefused(t *testing.T) {
+	rule := NewStegraNoTypeInNameRule()
+	cfg := `
+rule "stegra_no_type_in_name" {
+  enabled = true
+  fix     = true
+}
+`
+	files := map[string]string{
+		".tflint.hcl": cfg,
+		"main.tf": `resource "aws_security_group" "web" {}
+resource "aws_security_group" "web_security_group" {}
+resource "aws_vpc" "vpc" {}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "resource name `web_security_group` must not repeat type tokens (security, group); not fixed: `aws_security_group.web` already exists",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 2, Column: 31}, End: hcl.Pos{Line: 2, Column: 51}},
+		},
+		{
+			Rule:    rule,
+			Message: "resource name `vpc` must not repeat type tokens (vpc); not fixed: name would be empty after removing type tokens",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 3, Column: 20}, End: hcl.Pos{Line: 3, Column: 25}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{}, runner.Changes())
+}
+
+func Test_StegraNoTypeInNameRule_TokenMatchingOptions(t *testing.T) {
+	rule := NewStegraNoTypeInNameRule()
+	cases := []struct {
+		Name     string
+		Config   string
+		Content  string
+		Expected helper.Issues
+	}{
+		{
+			Name:     "allowed_tokens replaces the default allow-list",
+			Config:   `allowed_tokens = ["role"]`,
+			Content:  `resource "aws_iam_role" "deploy_role" {}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name:    "plural matching",
+			Config:  `match_plural = true`,
+			Content: `data "aws_iam_roles" "admin_role" {}`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "data name `admin_role` must not repeat type tokens (roles)",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 22}, End: hcl.Pos{Line: 1, Column: 34}},
+				},
+			},
+		},
+		{
+			Name:     "known provider prefix drops multi-token prefix",
+			Config:   `provider_prefixes = ["azurerm", "google_beta"]`,
+			Content:  `resource "google_beta_compute_instance" "beta_vm" {}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name:     "provider prefix length",
+			Config:   `provider_prefix_length = 2`,
+			Content:  `resource "google_compute_instance" "compute_vm" {}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name:     "full suffix only ignores single tokens",
+			Config:   `full_suffix_only = true`,
+			Content:  `resource "aws_security_group" "group_a" {}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name:    "full suffix only flags the whole suffix",
+			Config:  `full_suffix_only = true`,
+			Content: `resource "aws_security_group" "web_security_group" {}`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "resource name `web_security_group` must not repeat type tokens (security, group)",
+					Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 1, Column: 31}, End: hcl.Pos{Line: 1, Column: 51}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				".tflint.hcl": "rule \"stegra_no_type_in_name\" {\n  enabled = true\n  " + tc.Config + "\n}\n",
+				"main.tf":     tc.Content,
+			})
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_StegraNoTypeInNameRule_FixFullSuffixOnly(t *testing.T) {
+	rule := NewStegraNoTypeInNameRule()
+	files := map[string]string{
+		".tflint.hcl": `
+rule "stegra_no_type_in_name" {
+  enabled          = true
+  fix              = true
+  full_suffix_only = true
+}
+`,
+		"main.tf": `resource "aws_security_group" "group_admins_security_group" {}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	// Only the contiguous repeat of the suffix goes; the standalone `group` stays
+	helper.AssertChanges(t, map[string]string{
+		"main.tf": `resource "aws_security_group" "group_admins" {}
+
+moved {
+  from = aws_security_group.group_admins_security_group
+  to   = aws_security_group.group_admins
+}
+`,
+	}, runner.Changes())
+}
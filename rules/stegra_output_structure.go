@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StegraOutputStructureRule requires output blocks to be documented, to list their items in the
+// configured order and to be sensitive when their value references a sensitive variable.
+type StegraOutputStructureRule struct{ tflint.DefaultRule }
+
+func NewStegraOutputStructureRule() *StegraOutputStructureRule { return &StegraOutputStructureRule{} }
+func (r *StegraOutputStructureRule) Name() string              { return "stegra_output_structure" }
+func (r *StegraOutputStructureRule) Enabled() bool             { return true }
+func (r *StegraOutputStructureRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraOutputStructureRule) Link() string              { return "" }
+
+type stegraOutputStructureConfig struct {
+	// DescriptionScope is "all" (default) or "non_root"
+	DescriptionScope string `hclext:"description_scope,optional"`
+	// ModuleDirectories are the directory patterns holding non-root modules, default ["modules"]
+	ModuleDirectories []string `hclext:"module_directories,optional"`
+	Order             []string `hclext:"order,optional"`
+}
+
+var defaultOutputOrder = []string{"description", "value", "sensitive", "ephemeral"}
+
+func (r *StegraOutputStructureRule) Check(runner tflint.Runner) error {
+	cfg := stegraOutputStructureConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	switch cfg.DescriptionScope {
+	case "", "all", "non_root":
+	default:
+		return fmt.Errorf("%s: description_scope must be \"all\" or \"non_root\", got %q", r.Name(), cfg.DescriptionScope)
+	}
+	order := cfg.Order
+	if len(order) == 0 {
+		order = defaultOutputOrder
+	}
+
+	// tflint drops issues of a called module unless they point at a module variable, so non-root
+	// modules are told apart by the directory of the file instead of the module path
+	moduleDirs := cfg.ModuleDirectories
+	if len(moduleDirs) == 0 {
+		moduleDirs = []string{"modules"}
+	}
+	moduleDirs = normalizeDirPatterns(moduleDirs)
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	// Sensitive variables of the whole module, as outputs may live in another file
+	sensitiveVars := map[string]bool{}
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		for _, blk := range lf.Body.Blocks {
+			if blk.Type == "variable" && len(blk.Labels) > 0 && isStaticTrue(blk.Body.Attributes["sensitive"]) {
+				sensitiveVars[blk.Labels[0]] = true
+			}
+		}
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		rel := filepath.ToSlash(filepath.Clean(filename))
+		requireDescription := cfg.DescriptionScope != "non_root" || firstMatchingDir(rel, moduleDirs) != ""
+		for _, blk := range lf.Body.Blocks {
+			if blk.Type != "output" || len(blk.Labels) == 0 {
+				continue
+			}
+			name := blk.Labels[0]
+
+			if _, ok := blk.Body.Attributes["description"]; requireDescription && !ok {
+				if err := runner.EmitIssue(r, fmt.Sprintf("output `%s` must set `description`", name), blk.LabelRanges[0]); err != nil {
+					return err
+				}
+			}
+
+			if value, ok := blk.Body.Attributes["value"]; ok && len(sensitiveVars) > 0 && !isStaticTrue(blk.Body.Attributes["sensitive"]) {
+				refs := &varRefCollector{}
+				hclsyntax.Walk(value.Expr, refs)
+				for _, ref := range refs.refs {
+					if !sensitiveVars[ref.name] {
+						continue
+					}
+					msg := fmt.Sprintf("output `%s` must set `sensitive = true` because it references sensitive variable `var.%s`", name, ref.name)
+					if err := runner.EmitIssue(r, msg, ref.rng); err != nil {
+						return err
+					}
+					break
+				}
+			}
+
+			if err := emitItemOrder(runner, r, lf, blk, order, fmt.Sprintf("output `%s`", name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isStaticTrue reports whether attr is set to the literal value true.
+func isStaticTrue(attr *hclsyntax.Attribute) bool {
+	if attr == nil {
+		return false
+	}
+	v, diags := attr.Expr.Value(nil)
+	return !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.Bool && v.True()
+}
+
+// varRef is a var.<name> traversal and its source range.
+type varRef struct {
+	name string
+	rng  hcl.Range
+}
+
+// varRefCollector implements hclsyntax.Visitor to collect var.<name> traversals, like walkCollector.
+type varRefCollector struct {
+	refs []varRef
+}
+
+func (c *varRefCollector) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	e, ok := node.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(e.Traversal) < 2 || traversalStepName(e.Traversal[0]) != "var" {
+		return nil
+	}
+	if name := traversalStepName(e.Traversal[1]); name != "" {
+		c.refs = append(c.refs, varRef{name: name, rng: e.Range()})
+	}
+	return nil
+}
+
+func (c *varRefCollector) Exit(node hclsyntax.Node) hcl.Diagnostics { return nil }
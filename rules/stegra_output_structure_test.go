@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraOutputStructureRule(t *testing.T) {
+	rule := NewStegraOutputStructureRule()
+	files := map[string]string{
+		"variables.tf": `variable "password" {
+  type      = string
+  sensitive = true
+}
+`,
+		"outputs.tf": `output "connection" {
+  value       = "db://${var.password}@host"
+  description = "Connection string"
+}
+
+output "secret" {
+  description = "Secret"
+  value       = var.password
+  sensitive   = true
+}
+
+output "host" {
+  value = "host"
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "output `connection` must set `sensitive = true` because it references sensitive variable `var.password`",
+			Range:   hcl.Range{Filename: "outputs.tf", Start: hcl.Pos{Line: 2, Column: 25}, End: hcl.Pos{Line: 2, Column: 37}},
+		},
+		{
+			Rule:    rule,
+			Message: "output `connection` items must appear in this order: description, value",
+			Range:   hcl.Range{Filename: "outputs.tf", Start: hcl.Pos{Line: 2, Column: 3}, End: hcl.Pos{Line: 2, Column: 44}},
+		},
+		{
+			Rule:    rule,
+			Message: "output `host` must set `description`",
+			Range:   hcl.Range{Filename: "outputs.tf", Start: hcl.Pos{Line: 12, Column: 8}, End: hcl.Pos{Line: 12, Column: 14}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"outputs.tf": `output "connection" {
+  description = "Connection string"
+  value       = "db://${var.password}@host"
+}
+
+output "secret" {
+  description = "Secret"
+  value       = var.password
+  sensitive   = true
+}
+
+output "host" {
+  value = "host"
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraOutputStructureRule_DescriptionScope(t *testing.T) {
+	rule := NewStegraOutputStructureRule()
+	output := "output \"host\" {\n  value = \"host\"\n}\n"
+	files := map[string]string{
+		"outputs.tf":                 output,
+		"modules/network/outputs.tf": output,
+		"stacks/dns/outputs.tf":      output,
+		".tflint.hcl": `
+rule "stegra_output_structure" {
+  enabled           = true
+  description_scope = "non_root"
+}
+`,
+	}
+
+	// Only outputs under the module directories (default "modules") must be documented
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "output `host` must set `description`",
+			Range:   hcl.Range{Filename: "modules/network/outputs.tf", Start: hcl.Pos{Line: 1, Column: 8}, End: hcl.Pos{Line: 1, Column: 14}},
+		},
+	}, runner.Issues)
+
+	files[".tflint.hcl"] = `
+rule "stegra_output_structure" {
+  enabled            = true
+  description_scope  = "non_root"
+  module_directories = ["stacks/*"]
+}
+`
+	runner = helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "output `host` must set `description`",
+			Range:   hcl.Range{Filename: "stacks/dns/outputs.tf", Start: hcl.Pos{Line: 1, Column: 8}, End: hcl.Pos{Line: 1, Column: 14}},
+		},
+	}, runner.Issues)
+}
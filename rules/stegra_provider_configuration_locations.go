@@ -2,15 +2,20 @@ package rules
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
 )
 
-// StegraProviderBlockDisallowedDirsRule forbids provider blocks under configured directories.
+// StegraProviderBlockDisallowedDirsRule forbids provider blocks outside the configured directories.
 type StegraProviderConfigurationLocationsRule struct {
 	tflint.DefaultRule
 }
@@ -26,32 +31,70 @@ func (r *StegraProviderConfigurationLocationsRule) Severity() tflint.Severity {
 func (r *StegraProviderConfigurationLocationsRule) Link() string              { return "" }
 
 type providerDirsConfig struct {
-	Allowed []string `hclext:"allowed_directories,optional"`
+	Allowed  []string             `hclext:"allowed_directories,optional"`
+	Denied   []string             `hclext:"denied_directories,optional"`
+	Policies []providerDirsPolicy `hclext:"provider,block"`
+	// UnlistedProviders is "allow" or "deny" and decides providers without a policy when
+	// allowed_directories is not set
+	UnlistedProviders string `hclext:"unlisted_providers,optional"`
+	// CheckChildModules reports every provider block in a module called with a local source
+	CheckChildModules bool `hclext:"check_child_modules,optional"`
+}
+
+// providerDirsPolicy overrides the allowed directories for one provider, optionally only for
+// configurations whose alias matches the `alias` pattern ("*" matches any aliased configuration).
+type providerDirsPolicy struct {
+	Provider string   `hclext:"name,label"`
+	Alias    string   `hclext:"alias,optional"`
+	Allowed  []string `hclext:"allowed_directories,optional"`
+	Denied   []string `hclext:"denied_directories,optional"`
+}
+
+// policyFor returns the policy for a provider configuration. A policy with a matching alias
+// pattern wins over one without an alias; nil means the top-level directories apply.
+func (c providerDirsConfig) policyFor(provider, alias string) *providerDirsPolicy {
+	var fallback *providerDirsPolicy
+	for i := range c.Policies {
+		p := &c.Policies[i]
+		if p.Provider != provider {
+			continue
+		}
+		if p.Alias == "" {
+			if fallback == nil {
+				fallback = p
+			}
+			continue
+		}
+		if ok, _ := path.Match(p.Alias, alias); ok && alias != "" {
+			return p
+		}
+	}
+	return fallback
 }
 
 func (r *StegraProviderConfigurationLocationsRule) Check(runner tflint.Runner) error {
 	// Load required config
 	cfg := providerDirsConfig{}
 	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
-	if len(cfg.Allowed) == 0 {
+	if len(cfg.Allowed) == 0 && len(cfg.Policies) == 0 {
 		return fmt.Errorf("%s: allowed_directories option is required; set it in .tflint.hcl rule \"%s\"", r.Name(), r.Name())
 	}
-
-	// Normalize configured directories to slash-separated, cleaned prefixes
-	allowed := make([]string, 0, len(cfg.Allowed))
-	for _, d := range cfg.Allowed {
-		if d == "" {
-			continue
-		}
-		d = filepath.ToSlash(filepath.Clean(d))
-		allowed = append(allowed, d)
+	switch {
+	case len(cfg.Allowed) > 0 && cfg.UnlistedProviders != "":
+		return fmt.Errorf("%s: unlisted_providers only applies when allowed_directories is not set", r.Name())
+	case len(cfg.Allowed) == 0 && cfg.UnlistedProviders != "allow" && cfg.UnlistedProviders != "deny":
+		return fmt.Errorf("%s: unlisted_providers must be \"allow\" or \"deny\" when only provider policies are set, got %q", r.Name(), cfg.UnlistedProviders)
 	}
+	allowed := normalizeDirPatterns(cfg.Allowed)
+	denied := normalizeDirPatterns(cfg.Denied)
 
-	path, err := runner.GetModulePath()
+	modulePath, err := runner.GetModulePath()
 	if err != nil {
 		return err
 	}
-	if !path.IsRoot() {
+	// Issues in called modules are only kept by tflint when they point at a module variable, so
+	// provider blocks of child modules are reported on the calling module block instead
+	if !modulePath.IsRoot() {
 		return nil
 	}
 
@@ -59,6 +102,11 @@ func (r *StegraProviderConfigurationLocationsRule) Check(runner tflint.Runner) e
 	if err != nil {
 		return err
 	}
+	if cfg.CheckChildModules {
+		if err := r.checkModuleCalls(runner, files); err != nil {
+			return err
+		}
+	}
 
 	for filename, file := range files {
 		// Skip JSON
@@ -66,53 +114,244 @@ func (r *StegraProviderConfigurationLocationsRule) Check(runner tflint.Runner) e
 			continue
 		}
 
-		body, ok := file.Body.(*hclsyntax.Body)
-		if !ok {
+		body := layout.Get(filename, file).Body
+		if body == nil {
 			continue
 		}
 
 		rel := filepath.ToSlash(filepath.Clean(filename))
-		// Check if this file is under any allowed directory
-		var isAllowed bool
-		for _, d := range allowed {
-			if isUnderDir(rel, d) {
-				isAllowed = true
-				break
+		for _, blk := range body.Blocks {
+			if blk.Type != "provider" || len(blk.Labels) == 0 {
+				continue
+			}
+			provider := blk.Labels[0]
+			alias := providerAlias(blk)
+
+			subject := "provider block"
+			dirsAllowed, dirsDenied := allowed, denied
+			// origin names where the allowed directories come from when a policy inherits them
+			origin := ""
+			p := cfg.policyFor(provider, alias)
+			if p != nil {
+				subject = fmt.Sprintf("provider %q", provider)
+				if alias != "" {
+					subject = fmt.Sprintf("provider %q with alias %q", provider, alias)
+				}
+				if len(p.Allowed) > 0 {
+					dirsAllowed = normalizeDirPatterns(p.Allowed)
+				} else if len(allowed) > 0 {
+					origin = " (top-level allowed_directories)"
+				}
+				dirsDenied = append(append([]string(nil), denied...), normalizeDirPatterns(p.Denied)...)
+			}
+
+			// Denied directories override any allow
+			msg := ""
+			if d := firstMatchingDir(rel, dirsDenied); d != "" {
+				msg = fmt.Sprintf("%s is not allowed under: %s", subject, d)
+			} else if p == nil && len(allowed) == 0 && cfg.UnlistedProviders == "deny" {
+				msg = fmt.Sprintf("provider %q has no provider policy and unlisted_providers is \"deny\"", provider)
+			} else if len(dirsAllowed) > 0 && firstMatchingDir(rel, dirsAllowed) == "" {
+				msg = fmt.Sprintf("%s is only allowed under: %s%s", subject, strings.Join(dirsAllowed, ", "), origin)
+			}
+			if msg == "" {
+				continue
+			}
+			// Highlight the 'provider' keyword
+			issueRange := hcl.Range{Filename: filename, Start: blk.TypeRange.Start, End: blk.TypeRange.End}
+			if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+				return err
 			}
 		}
+	}
+
+	return nil
+}
+
+// checkModuleCalls reports every provider block of a module called with a local source, on the
+// `source` of the call: a module that configures its own providers cannot be used with count,
+// for_each or depends_on, so the caller must pass them in. Local modules called from a child
+// module are followed as well and reported on the call in the root module.
+func (r *StegraProviderConfigurationLocationsRule) checkModuleCalls(runner tflint.Runner, files map[string]*hcl.File) error {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
 
-		if !isAllowed {
-			for _, blk := range body.Blocks {
-				if blk.Type != "provider" {
+	for _, filename := range filenames {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		body := layout.Get(filename, files[filename]).Body
+		if body == nil {
+			continue
+		}
+		for _, call := range body.Blocks {
+			dir, srcRange, ok := localModuleDir(filename, call)
+			if !ok {
+				continue
+			}
+			visited := map[string]bool{}
+			queue := []string{dir}
+			for len(queue) > 0 {
+				dir, queue = queue[0], queue[1:]
+				if visited[dir] {
 					continue
 				}
-				// Highlight the 'provider' keyword
-				issueRange := hcl.Range{Filename: filename, Start: blk.TypeRange.Start, End: blk.TypeRange.End}
-				msg := fmt.Sprintf("provider block is only allowed under: %s", strings.Join(allowed, ", "))
-				if err := runner.EmitIssue(r, msg, issueRange); err != nil {
+				visited[dir] = true
+				childFiles, err := readModuleDir(dir)
+				if err != nil {
 					return err
 				}
+				for _, child := range childFiles {
+					for _, blk := range child.Body.Blocks {
+						if blk.Type == "module" {
+							if nested, _, ok := localModuleDir(child.Filename, blk); ok {
+								queue = append(queue, nested)
+							}
+							continue
+						}
+						if blk.Type != "provider" || len(blk.Labels) == 0 {
+							continue
+						}
+						provider := blk.Labels[0]
+						where := fmt.Sprintf("%s:%d", filepath.ToSlash(child.Filename), blk.TypeRange.Start.Line)
+						msg := fmt.Sprintf("module `%s` configures a provider in %s; remove it and pass the default configuration from the calling module with providers = { %s = %s }", call.Labels[0], where, provider, provider)
+						if alias := providerAlias(blk); alias != "" {
+							msg = fmt.Sprintf("module `%s` configures a provider in %s; declare configuration_aliases = [%s.%s] in its required_providers and pass it from the calling module with providers = { %s.%s = %s.<alias> }", call.Labels[0], where, provider, alias, provider, alias, provider)
+						}
+						if err := runner.EmitIssue(r, msg, srcRange); err != nil {
+							return err
+						}
+					}
+				}
 			}
 		}
 	}
-
 	return nil
 }
 
-func isUnderDir(path, dir string) bool {
+// localModuleDir returns the directory of a module call with a static local source, relative to
+// the working directory like the calling file, and the range of its source.
+func localModuleDir(filename string, blk *hclsyntax.Block) (string, hcl.Range, bool) {
+	if blk.Type != "module" || len(blk.Labels) == 0 {
+		return "", hcl.Range{}, false
+	}
+	attr, ok := blk.Body.Attributes["source"]
+	if !ok {
+		return "", hcl.Range{}, false
+	}
+	src, ok := staticString(attr.Expr)
+	if !ok || parseModuleSource(src).class != "local" {
+		return "", hcl.Range{}, false
+	}
+	return filepath.Join(filepath.Dir(filename), src), attr.Expr.Range(), true
+}
+
+// readModuleDir parses the .tf files of a called module from disk, as the runner only serves the
+// files of the module it checks. Files that do not parse are skipped; a missing directory has none.
+func readModuleDir(dir string) ([]*layout.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := []*layout.File{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".tf" {
+			continue
+		}
+		filename := filepath.Join(dir, e.Name())
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		out = append(out, lf)
+	}
+	return out, nil
+}
+
+// providerAlias returns the static value of the block's alias attribute, or "" for the default configuration.
+func providerAlias(blk *hclsyntax.Block) string {
+	attr, ok := blk.Body.Attributes["alias"]
+	if !ok {
+		return ""
+	}
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return ""
+	}
+	return v.AsString()
+}
+
+// normalizeDirPatterns cleans configured directory patterns to slash-separated form.
+func normalizeDirPatterns(dirs []string) []string {
+	out := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		if d == "" {
+			continue
+		}
+		out = append(out, filepath.ToSlash(filepath.Clean(d)))
+	}
+	return out
+}
+
+// firstMatchingDir returns the first pattern that contains the file, or "".
+func firstMatchingDir(file string, patterns []string) string {
+	for _, p := range patterns {
+		if isUnderDir(file, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// isUnderDir reports whether file lies in a directory matched by dir. Each segment of dir is a
+// glob (`*` matches one directory name) and `**` matches any number of directories. The pattern
+// only needs to match a leading part of the file's directory, so `environments/*` covers every
+// file below any environment.
+func isUnderDir(file, dir string) bool {
 	if dir == "" {
 		return false
 	}
 	if dir == "." {
 		// Treat '.' as repository root files (no directory component)
-		return !strings.Contains(path, "/")
+		return !strings.Contains(file, "/")
 	}
-	// Ensure dir ends with a slash for boundary match
-	d := dir
-	if !strings.HasSuffix(d, "/") {
-		d += "/"
-	}
-	// Add leading boundary to avoid matching middle segments incorrectly if dir is relative
 	// We operate on cleaned, slash-separated relative paths as provided by TestRunner.
-	return path == dir || strings.HasPrefix(path, d)
+	segs := strings.Split(file, "/")
+	return matchDirSegments(strings.Split(dir, "/"), segs[:len(segs)-1])
+}
+
+// matchDirSegments reports whether pattern matches a leading run of the directory segments.
+func matchDirSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchDirSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+		return false
+	}
+	return matchDirSegments(pattern[1:], segs[1:])
 }
@@ -1,8 +1,11 @@
 package rules
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/terraform-linters/tflint-plugin-sdk/helper"
 )
 
@@ -57,3 +60,215 @@ rule "stegra_provider_configuration_locations" {
 		}
 	})
 }
+
+func Test_StegraProviderConfigurationLocationsRule_Patterns(t *testing.T) {
+	rule := NewStegraProviderConfigurationLocationsRule()
+	files := map[string]string{
+		"environments/prod/main.tf":     `provider "aws" {}`,
+		"environments/main.tf":          `provider "aws" {}`,
+		"environments/sandbox/main.tf":  `provider "aws" {}`,
+		"stacks/network/eu/main.tf":     "provider \"aws\" {\n  alias = \"eu\"\n}\n",
+		"stacks/network/main.tf":        `provider "aws" {}`,
+		"clusters/blue/providers.tf":    `provider "kubernetes" {}`,
+		"environments/prod/clusters.tf": `provider "kubernetes" {}`,
+		".tflint.hcl": `
+rule "stegra_provider_configuration_locations" {
+  enabled             = true
+  allowed_directories = ["environments/*/"]
+  denied_directories  = ["environments/sandbox"]
+
+  provider "aws" {
+    alias               = "*"
+    allowed_directories = ["stacks/**"]
+  }
+
+  provider "kubernetes" {
+    allowed_directories = ["clusters/**"]
+  }
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "provider block is only allowed under: environments/*",
+			Range:   hcl.Range{Filename: "environments/main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+		{
+			Rule:    rule,
+			Message: "provider \"kubernetes\" is only allowed under: clusters/**",
+			Range:   hcl.Range{Filename: "environments/prod/clusters.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+		{
+			Rule:    rule,
+			Message: "provider block is not allowed under: environments/sandbox",
+			Range:   hcl.Range{Filename: "environments/sandbox/main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+		{
+			Rule:    rule,
+			Message: "provider block is only allowed under: environments/*",
+			Range:   hcl.Range{Filename: "stacks/network/main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+	}, runner.Issues)
+}
+
+func Test_StegraProviderConfigurationLocationsRule_UnlistedProviders(t *testing.T) {
+	rule := NewStegraProviderConfigurationLocationsRule()
+	content := "provider \"kubernetes\" {}\nprovider \"aws\" {}\n"
+	policies := `
+  provider "kubernetes" {
+    allowed_directories = ["clusters/**"]
+  }
+}
+`
+	cases := []struct {
+		Name     string
+		Config   string
+		Error    string
+		Expected helper.Issues
+	}{
+		{
+			Name:   "fallback must be explicit",
+			Config: "rule \"stegra_provider_configuration_locations\" {\n  enabled = true\n" + policies,
+			Error:  `stegra_provider_configuration_locations: unlisted_providers must be "allow" or "deny" when only provider policies are set, got ""`,
+		},
+		{
+			Name:     "allow",
+			Config:   "rule \"stegra_provider_configuration_locations\" {\n  enabled            = true\n  unlisted_providers = \"allow\"\n" + policies,
+			Expected: helper.Issues{},
+		},
+		{
+			Name:   "deny",
+			Config: "rule \"stegra_provider_configuration_locations\" {\n  enabled            = true\n  unlisted_providers = \"deny\"\n" + policies,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "provider \"aws\" has no provider policy and unlisted_providers is \"deny\"",
+					Range:   hcl.Range{Filename: "clusters/blue/main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 9}},
+				},
+			},
+		},
+		{
+			Name: "policy inheriting the top-level directories",
+			Config: `
+rule "stegra_provider_configuration_locations" {
+  enabled             = true
+  allowed_directories = ["environments"]
+
+  provider "aws" {
+    denied_directories = ["environments/sandbox"]
+  }
+}
+`,
+			Expected: helper.Issues{
+				{
+					Rule:    rule,
+					Message: "provider block is only allowed under: environments",
+					Range:   hcl.Range{Filename: "clusters/blue/main.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+				},
+				{
+					Rule:    rule,
+					Message: "provider \"aws\" is only allowed under: environments (top-level allowed_directories)",
+					Range:   hcl.Range{Filename: "clusters/blue/main.tf", Start: hcl.Pos{Line: 2, Column: 1}, End: hcl.Pos{Line: 2, Column: 9}},
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				".tflint.hcl":           tc.Config,
+				"clusters/blue/main.tf": content,
+			})
+			err := rule.Check(runner)
+			if tc.Error != "" {
+				if err == nil || err.Error() != tc.Error {
+					t.Fatalf("expected error %q, got %v", tc.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_StegraProviderConfigurationLocationsRule_ChildModules(t *testing.T) {
+	rule := NewStegraProviderConfigurationLocationsRule()
+
+	// Called modules are read from disk, relative to the working directory
+	t.Chdir(t.TempDir())
+	for filename, content := range map[string]string{
+		"modules/net/main.tf":     "provider \"aws\" {}\n\nmodule \"subnets\" {\n  source = \"../subnets\"\n}\n",
+		"modules/subnets/main.tf": "provider \"aws\" {\n  alias = \"east\"\n}\n",
+		"modules/clean/main.tf":   "resource \"aws_vpc\" \"main\" {}\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		"main.tf": `provider "aws" {}
+
+module "net" {
+  source = "./modules/net"
+}
+
+module "clean" {
+  source = "./modules/clean"
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+}
+`,
+		".tflint.hcl": `
+rule "stegra_provider_configuration_locations" {
+  enabled             = true
+  allowed_directories = ["."]
+  check_child_modules = true
+}
+`,
+	}
+
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "module `net` configures a provider in modules/net/main.tf:1; remove it and pass the default configuration from the calling module with providers = { aws = aws }",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 12}, End: hcl.Pos{Line: 4, Column: 27}},
+		},
+		{
+			Rule:    rule,
+			Message: "module `net` configures a provider in modules/subnets/main.tf:1; declare configuration_aliases = [aws.east] in its required_providers and pass it from the calling module with providers = { aws.east = aws.<alias> }",
+			Range:   hcl.Range{Filename: "main.tf", Start: hcl.Pos{Line: 4, Column: 12}, End: hcl.Pos{Line: 4, Column: 27}},
+		},
+	}, runner.Issues)
+
+	// Without the option called modules are left alone
+	files[".tflint.hcl"] = `
+rule "stegra_provider_configuration_locations" {
+  enabled             = true
+  allowed_directories = ["."]
+}
+`
+	runner = helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+}
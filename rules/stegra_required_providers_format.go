@@ -0,0 +1,287 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StegraRequiredProvidersFormatRule enforces that terraform.required_providers entries are sorted by
+// name, list `source` before `version` and pin the version according to `version_policy`.
+type StegraRequiredProvidersFormatRule struct{ tflint.DefaultRule }
+
+func NewStegraRequiredProvidersFormatRule() *StegraRequiredProvidersFormatRule {
+	return &StegraRequiredProvidersFormatRule{}
+}
+func (r *StegraRequiredProvidersFormatRule) Name() string {
+	return "stegra_required_providers_format"
+}
+func (r *StegraRequiredProvidersFormatRule) Enabled() bool             { return true }
+func (r *StegraRequiredProvidersFormatRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraRequiredProvidersFormatRule) Link() string              { return "" }
+
+type stegraRequiredProvidersFormatConfig struct {
+	// VersionPolicy is "exact" (default), "~>" or "any"
+	VersionPolicy string `hclext:"version_policy,optional"`
+}
+
+var (
+	exactVersionPattern       = regexp.MustCompile(`^=?\s*\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+	pessimisticVersionPattern = regexp.MustCompile(`^~>\s*\d+(\.\d+){1,2}(-[0-9A-Za-z.-]+)?$`)
+)
+
+func (r *StegraRequiredProvidersFormatRule) Check(runner tflint.Runner) error {
+	cfg := stegraRequiredProvidersFormatConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	policy := cfg.VersionPolicy
+	switch policy {
+	case "":
+		policy = "exact"
+	case "exact", "~>", "any":
+	default:
+		return fmt.Errorf("%s: version_policy must be one of \"exact\", \"~>\" or \"any\", got %q", r.Name(), policy)
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		for _, tf := range lf.Body.Blocks {
+			if tf.Type != "terraform" {
+				continue
+			}
+			for _, rp := range tf.Body.Blocks {
+				if rp.Type != "required_providers" {
+					continue
+				}
+				if err := r.checkRequiredProviders(runner, lf, rp, policy); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// providerEntry is one required_providers attribute with the canonical order of its object keys.
+type providerEntry struct {
+	item     layout.Item
+	obj      *hclsyntax.ObjectConsExpr
+	keys     []string
+	keyOrder []int
+}
+
+func (r *StegraRequiredProvidersFormatRule) checkRequiredProviders(runner tflint.Runner, lf *layout.File, rp *hclsyntax.Block, policy string) error {
+	items := lf.Items(rp.Body)
+	entries := make([]providerEntry, len(items))
+	for i, it := range items {
+		entries[i].item = it
+		if it.Kind != layout.Attribute {
+			continue
+		}
+		obj, ok := it.Attr.Expr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			continue
+		}
+		entries[i].obj = obj
+		// Canonical key order: source, version, then the other keys in source order
+		rank := func(k string) int {
+			switch k {
+			case "source":
+				return 0
+			case "version":
+				return 1
+			}
+			return 2
+		}
+		for j, oi := range obj.Items {
+			entries[i].keys = append(entries[i].keys, objectKeyName(oi))
+			entries[i].keyOrder = append(entries[i].keyOrder, j)
+		}
+		keys := entries[i].keys
+		sort.SliceStable(entries[i].keyOrder, func(a, b int) bool {
+			return rank(keys[entries[i].keyOrder[a]]) < rank(keys[entries[i].keyOrder[b]])
+		})
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return items[order[a]].Name < items[order[b]].Name })
+
+	// Issues that the single fix of this block resolves
+	type fixable struct {
+		msg string
+		rng hcl.Range
+	}
+	issues := []fixable{}
+	for pos, idx := range order {
+		if pos != idx {
+			names := make([]string, len(order))
+			for i, o := range order {
+				names[i] = items[o].Name
+			}
+			issues = append(issues, fixable{
+				fmt.Sprintf("required_providers entries must be sorted by name: %s", strings.Join(names, ", ")),
+				entryNameRange(items[pos]),
+			})
+			break
+		}
+	}
+	for _, e := range entries {
+		for pos, j := range e.keyOrder {
+			if pos != j {
+				issues = append(issues, fixable{
+					fmt.Sprintf("required provider `%s` must list source and then version first", e.item.Name),
+					e.obj.Items[pos].KeyExpr.Range(),
+				})
+				break
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		rng, text, ok := r.rewrite(lf, rp, entries, order)
+		for n, is := range issues {
+			if n == 0 && ok {
+				if err := runner.EmitIssueWithFix(r, is.msg, is.rng, func(fixer tflint.Fixer) error {
+					return fixer.ReplaceText(rng, text)
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := runner.EmitIssue(r, is.msg, is.rng); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Version pinning is reported per entry and never fixed
+	for _, e := range entries {
+		if e.item.Kind != layout.Attribute {
+			continue
+		}
+		if e.obj == nil {
+			if err := runner.EmitIssue(r, fmt.Sprintf("required provider `%s` must use the object form with source and version", e.item.Name), entryNameRange(e.item)); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg, rng := versionIssue(e, policy); msg != "" {
+			if err := runner.EmitIssue(r, msg, rng); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rewrite returns the required_providers body with the entries sorted and the keys of each entry
+// in canonical order. It reports false when an entry or key shares a line with another or a brace.
+func (r *StegraRequiredProvidersFormatRule) rewrite(lf *layout.File, rp *hclsyntax.Block, entries []providerEntry, order []int) (hcl.Range, string, bool) {
+	items := make([]layout.Item, len(entries))
+	for i, e := range entries {
+		items[i] = e.item
+	}
+	// Keys move line by line within their entry; lines between them (comments, blanks) stay in place
+	keyed := map[int]func(string) string{}
+	for i, e := range entries {
+		sorted := true
+		for pos, j := range e.keyOrder {
+			sorted = sorted && pos == j
+		}
+		if sorted {
+			continue
+		}
+		keySpans := make([][2]int, len(e.obj.Items))
+		keyTexts := make([]string, len(e.obj.Items))
+		prevEnd := e.obj.OpenRange.End.Line
+		for j, oi := range e.obj.Items {
+			start, end := oi.KeyExpr.Range().Start.Line, oi.ValueExpr.Range().End.Line
+			if start <= prevEnd {
+				return hcl.Range{}, "", false
+			}
+			prevEnd = end
+			keySpans[j] = [2]int{lf.LineStart(start), lf.LineStart(end + 1)}
+			keyTexts[j] = string(lf.Src[keySpans[j][0]:keySpans[j][1]])
+		}
+		if prevEnd >= e.obj.SrcRange.End.Line {
+			return hcl.Range{}, "", false
+		}
+		itemStart, _ := lf.ItemLines(e.item, true)
+		from, to := keySpans[0][0]-itemStart, keySpans[len(keySpans)-1][1]-itemStart
+		keys := reorderedSpans(lf.Src, keySpans, keyTexts, e.keyOrder)
+		keyed[i] = func(text string) string { return text[:from] + keys + text[to:] }
+	}
+	return reorderedItems(lf, rp, items, order, func(pos int, text string) string {
+		if edit, ok := keyed[order[pos]]; ok {
+			return edit(text)
+		}
+		return text
+	})
+}
+
+// entryNameRange highlights the name of a required_providers entry.
+func entryNameRange(it layout.Item) hcl.Range {
+	if it.Kind == layout.Block {
+		return it.Block.TypeRange
+	}
+	return it.Attr.NameRange
+}
+
+// versionIssue checks the version constraint of an entry against the policy.
+func versionIssue(e providerEntry, policy string) (string, hcl.Range) {
+	for j, key := range e.keys {
+		if key != "version" {
+			continue
+		}
+		expr := e.obj.Items[j].ValueExpr
+		v, diags := expr.Value(nil)
+		if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+			return "", hcl.Range{}
+		}
+		constraint := strings.TrimSpace(v.AsString())
+		switch {
+		case policy == "exact" && !exactVersionPattern.MatchString(constraint):
+			return fmt.Sprintf("required provider `%s` must pin an exact version, got %q", e.item.Name, constraint), expr.Range()
+		case policy == "~>" && !pessimisticVersionPattern.MatchString(constraint):
+			return fmt.Sprintf("required provider `%s` must use a `~>` version constraint, got %q", e.item.Name, constraint), expr.Range()
+		case constraint == "":
+			return fmt.Sprintf("required provider `%s` must set a version", e.item.Name), expr.Range()
+		}
+		return "", hcl.Range{}
+	}
+	return fmt.Sprintf("required provider `%s` must set a version", e.item.Name), e.item.Attr.NameRange
+}
+
+// objectKeyName returns the key of an object constructor item as written, or "" for computed keys.
+func objectKeyName(item hclsyntax.ObjectConsItem) string {
+	if name := hcl.ExprAsKeyword(item.KeyExpr); name != "" {
+		return name
+	}
+	v, diags := item.KeyExpr.Value(nil)
+	if diags.HasErrors() || !v.IsKnown() || v.IsNull() || v.Type() != cty.String {
+		return ""
+	}
+	return v.AsString()
+}
@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraRequiredProvidersFormatRule(t *testing.T) {
+	rule := NewStegraRequiredProvidersFormatRule()
+	files := map[string]string{
+		"versions.tf": `terraform {
+  required_providers {
+    random = {
+      source  = "hashicorp/random"
+      version = "3.7.2"
+    }
+    # main cloud
+    aws = {
+      version = "6.20.0"
+      source  = "hashicorp/aws"
+    }
+    gitlab = {
+      source  = "gitlabhq/gitlab"
+      version = "~> 18.5"
+    }
+  }
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "required_providers entries must be sorted by name: aws, gitlab, random",
+			Range:   hcl.Range{Filename: "versions.tf", Start: hcl.Pos{Line: 3, Column: 5}, End: hcl.Pos{Line: 3, Column: 11}},
+		},
+		{
+			Rule:    rule,
+			Message: "required provider `aws` must list source and then version first",
+			Range:   hcl.Range{Filename: "versions.tf", Start: hcl.Pos{Line: 9, Column: 7}, End: hcl.Pos{Line: 9, Column: 14}},
+		},
+		{
+			Rule:    rule,
+			Message: "required provider `gitlab` must pin an exact version, got \"~> 18.5\"",
+			Range:   hcl.Range{Filename: "versions.tf", Start: hcl.Pos{Line: 14, Column: 17}, End: hcl.Pos{Line: 14, Column: 26}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"versions.tf": `terraform {
+  required_providers {
+    # main cloud
+    aws = {
+      source  = "hashicorp/aws"
+      version = "6.20.0"
+    }
+    gitlab = {
+      source  = "gitlabhq/gitlab"
+      version = "~> 18.5"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "3.7.2"
+    }
+  }
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraRequiredProvidersFormatRule_VersionPolicy(t *testing.T) {
+	rule := NewStegraRequiredProvidersFormatRule()
+	src := `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "6.20.0"
+    }
+    gitlab = {
+      source = "gitlabhq/gitlab"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = ">= 3.0"
+    }
+  }
+}
+`
+	cases := []struct {
+		Policy   string
+		Expected []string
+	}{
+		{"~>", []string{
+			"required provider `aws` must use a `~>` version constraint, got \"6.20.0\"",
+			"required provider `gitlab` must set a version",
+			"required provider `random` must use a `~>` version constraint, got \">= 3.0\"",
+		}},
+		{"any", []string{
+			"required provider `gitlab` must set a version",
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Policy, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				"versions.tf": src,
+				".tflint.hcl": `
+rule "stegra_required_providers_format" {
+  enabled        = true
+  version_policy = "` + tc.Policy + `"
+}
+`,
+			})
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			expected := helper.Issues{}
+			for _, msg := range tc.Expected {
+				expected = append(expected, &helper.Issue{Rule: rule, Message: msg})
+			}
+			helper.AssertIssuesWithoutRange(t, expected, runner.Issues)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraSortedVariablesOutputsRule requires variable and output blocks to be sorted by name within
+// each file, optionally with required variables (no default) first.
+type StegraSortedVariablesOutputsRule struct{ tflint.DefaultRule }
+
+func NewStegraSortedVariablesOutputsRule() *StegraSortedVariablesOutputsRule {
+	return &StegraSortedVariablesOutputsRule{}
+}
+func (r *StegraSortedVariablesOutputsRule) Name() string              { return "stegra_sorted_variables_outputs" }
+func (r *StegraSortedVariablesOutputsRule) Enabled() bool             { return true }
+func (r *StegraSortedVariablesOutputsRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraSortedVariablesOutputsRule) Link() string              { return "" }
+
+type stegraSortedVariablesOutputsConfig struct {
+	RequiredFirst bool `hclext:"required_first,optional"`
+}
+
+func (r *StegraSortedVariablesOutputsRule) Check(runner tflint.Runner) error {
+	cfg := stegraSortedVariablesOutputsConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		items := lf.Items(lf.Body)
+
+		// Blocks of each kind are sorted among the positions that kind already occupies;
+		// every other item stays where it is
+		order := make([]int, len(items))
+		for i := range order {
+			order[i] = i
+		}
+		type issue struct {
+			msg string
+			rng hcl.Range
+		}
+		issues := []issue{}
+		for _, kind := range []string{"variable", "output"} {
+			slots := []int{}
+			for i, it := range items {
+				if it.Kind == layout.Block && it.Name == kind && len(it.Block.Labels) > 0 {
+					slots = append(slots, i)
+				}
+			}
+			sorted := append([]int(nil), slots...)
+			sort.SliceStable(sorted, func(a, b int) bool {
+				return namedBlockBefore(items[sorted[a]].Block, items[sorted[b]].Block, cfg.RequiredFirst)
+			})
+			reported := false
+			for n, slot := range slots {
+				order[slot] = sorted[n]
+				if slot == sorted[n] || reported {
+					continue
+				}
+				reported = true
+				msg := fmt.Sprintf("%s blocks must be sorted by name", kind)
+				if kind == "variable" && cfg.RequiredFirst {
+					msg = "variable blocks must list required variables first, each group sorted by name"
+				}
+				issues = append(issues, issue{msg, items[slot].Block.TypeRange})
+			}
+		}
+		if len(issues) == 0 {
+			continue
+		}
+
+		// One fix per file rewrites every misplaced block at once
+		rng, text, ok := reorderedBlocks(lf, items, order)
+		for n, is := range issues {
+			if n == 0 && ok {
+				if err := runner.EmitIssueWithFix(r, is.msg, is.rng, func(fixer tflint.Fixer) error {
+					return fixer.ReplaceText(rng, text)
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := runner.EmitIssue(r, is.msg, is.rng); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// namedBlockBefore reports whether block a sorts before block b of the same kind: by name, with
+// variables without a default first when requiredFirst is set. stegra_block_order sorts with it
+// as well, so the two rules never disagree on the order of variables.
+func namedBlockBefore(a, b *hclsyntax.Block, requiredFirst bool) bool {
+	if requiredFirst && a.Type == "variable" {
+		_, aDefault := a.Body.Attributes["default"]
+		_, bDefault := b.Body.Attributes["default"]
+		if aDefault != bDefault {
+			return bDefault
+		}
+	}
+	return strings.Join(a.Labels, ".") < strings.Join(b.Labels, ".")
+}
@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraSortedVariablesOutputsRule(t *testing.T) {
+	rule := NewStegraSortedVariablesOutputsRule()
+	files := map[string]string{
+		"variables.tf": `variable "zone" {
+  type    = string
+  default = "a"
+}
+# Service name
+variable "name" {
+  type = string
+}
+
+variable "id" {
+  type = string
+}
+`,
+		"outputs.tf": `output "b" {
+  value = 1
+}
+
+output "a" {
+  value = 2
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "output blocks must be sorted by name",
+			Range:   hcl.Range{Filename: "outputs.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 7}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable blocks must be sorted by name",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"variables.tf": `variable "id" {
+  type = string
+}
+
+# Service name
+variable "name" {
+  type = string
+}
+
+variable "zone" {
+  type    = string
+  default = "a"
+}
+`,
+		"outputs.tf": `output "a" {
+  value = 2
+}
+
+output "b" {
+  value = 1
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraSortedVariablesOutputsRule_RequiredFirst(t *testing.T) {
+	rule := NewStegraSortedVariablesOutputsRule()
+	files := map[string]string{
+		"variables.tf": `variable "a" {
+  default = 1
+}
+
+variable "c" {}
+
+variable "b" {}
+`,
+		".tflint.hcl": `
+rule "stegra_sorted_variables_outputs" {
+  enabled        = true
+  required_first = true
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "variable blocks must list required variables first, each group sorted by name",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 1, Column: 1}, End: hcl.Pos{Line: 1, Column: 9}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"variables.tf": `variable "b" {}
+
+variable "c" {}
+
+variable "a" {
+  default = 1
+}
+`,
+	}, runner.Changes())
+}
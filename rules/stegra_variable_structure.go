@@ -0,0 +1,158 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stegraab/tflint-ruleset-stegra/internal/layout"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// StegraVariableStructureRule requires variable blocks to set the configured attributes, to list
+// their items in the configured order and to avoid `type = any`.
+type StegraVariableStructureRule struct{ tflint.DefaultRule }
+
+func NewStegraVariableStructureRule() *StegraVariableStructureRule {
+	return &StegraVariableStructureRule{}
+}
+func (r *StegraVariableStructureRule) Name() string              { return "stegra_variable_structure" }
+func (r *StegraVariableStructureRule) Enabled() bool             { return true }
+func (r *StegraVariableStructureRule) Severity() tflint.Severity { return tflint.ERROR }
+func (r *StegraVariableStructureRule) Link() string              { return "" }
+
+type stegraVariableStructureConfig struct {
+	Required []string `hclext:"required,optional"`
+	Order    []string `hclext:"order,optional"`
+	AllowAny []string `hclext:"allow_any,optional"`
+}
+
+var (
+	defaultVariableRequired = []string{"description", "type"}
+	defaultVariableOrder    = []string{"type", "description", "default", "sensitive", "nullable", "ephemeral", "validation"}
+)
+
+func (r *StegraVariableStructureRule) Check(runner tflint.Runner) error {
+	cfg := stegraVariableStructureConfig{}
+	_ = runner.DecodeRuleConfig(r.Name(), &cfg)
+	required := cfg.Required
+	if required == nil {
+		required = defaultVariableRequired
+	}
+	order := cfg.Order
+	if len(order) == 0 {
+		order = defaultVariableOrder
+	}
+	allowAny := map[string]bool{}
+	for _, n := range cfg.AllowAny {
+		allowAny[n] = true
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return err
+	}
+
+	for filename, file := range files {
+		if filepath.Ext(filename) != ".tf" {
+			continue
+		}
+		lf := layout.Get(filename, file)
+		if lf.Body == nil {
+			continue
+		}
+		for _, blk := range lf.Body.Blocks {
+			if blk.Type != "variable" || len(blk.Labels) == 0 {
+				continue
+			}
+			name := blk.Labels[0]
+			labelRange := blk.LabelRanges[0]
+
+			for _, attr := range required {
+				if _, ok := blk.Body.Attributes[attr]; ok {
+					continue
+				}
+				if err := runner.EmitIssue(r, fmt.Sprintf("variable `%s` must set `%s`", name, attr), labelRange); err != nil {
+					return err
+				}
+			}
+
+			if typ, ok := blk.Body.Attributes["type"]; ok && !allowAny[name] && hcl.ExprAsKeyword(typ.Expr) == "any" {
+				if err := runner.EmitIssue(r, fmt.Sprintf("variable `%s` must not use `type = any`", name), typ.Expr.Range()); err != nil {
+					return err
+				}
+			}
+
+			if err := emitItemOrder(runner, r, lf, blk, order, fmt.Sprintf("variable `%s`", name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// emitItemOrder reports the first item of blk that is out of the configured order and fixes the
+// whole block in one pass, moving each item with its leading comments (see reorderedItems).
+// Items missing from the order list go last, in source order.
+func emitItemOrder(runner tflint.Runner, rule tflint.Rule, lf *layout.File, blk *hclsyntax.Block, order []string, subject string) error {
+	rank := map[string]int{}
+	for i, n := range order {
+		if _, dup := rank[n]; !dup {
+			rank[n] = i
+		}
+	}
+	rankOf := func(name string) int {
+		if rk, ok := rank[name]; ok {
+			return rk
+		}
+		return len(order)
+	}
+
+	items := lf.Items(blk.Body)
+	canonical := make([]int, len(items))
+	for i := range canonical {
+		canonical[i] = i
+	}
+	sort.SliceStable(canonical, func(a, b int) bool { return rankOf(items[canonical[a]].Name) < rankOf(items[canonical[b]].Name) })
+
+	misplaced := -1
+	for pos, idx := range canonical {
+		if pos != idx {
+			misplaced = pos
+			break
+		}
+	}
+	if misplaced < 0 {
+		return nil
+	}
+
+	expected := []string{}
+	for i, idx := range canonical {
+		if _, ok := rank[items[idx].Name]; !ok {
+			break
+		}
+		if i == 0 || items[canonical[i-1]].Name != items[idx].Name {
+			expected = append(expected, items[idx].Name)
+		}
+	}
+	msg := fmt.Sprintf("%s items must appear in this order: %s", subject, strings.Join(expected, ", "))
+	issueRange := items[misplaced].Range
+	if items[misplaced].Kind == layout.Block {
+		issueRange = items[misplaced].Block.TypeRange
+	}
+
+	rng, text, ok := reorderedItems(lf, blk, items, canonical, nil)
+	if !ok {
+		return runner.EmitIssue(rule, msg, issueRange)
+	}
+	return runner.EmitIssueWithFix(
+		rule,
+		msg,
+		issueRange,
+		func(fixer tflint.Fixer) error { return fixer.ReplaceText(rng, text) },
+	)
+}
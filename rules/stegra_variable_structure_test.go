@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_StegraVariableStructureRule(t *testing.T) {
+	rule := NewStegraVariableStructureRule()
+	files := map[string]string{
+		"variables.tf": `variable "name" {
+  validation {
+    condition     = length(var.name) > 0
+    error_message = "name must not be empty"
+  }
+  default = "web"
+  # what it is for
+  description = "Name of the service"
+  type        = string
+}
+
+variable "settings" {
+  type = any
+}
+
+variable "extra" {
+  description = "Free-form settings"
+  type        = any
+}
+`,
+		".tflint.hcl": `
+rule "stegra_variable_structure" {
+  enabled   = true
+  allow_any = ["extra"]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "variable `name` items must appear in this order: type, description, default, validation",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 2, Column: 3}, End: hcl.Pos{Line: 2, Column: 13}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable `settings` must set `description`",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 12, Column: 10}, End: hcl.Pos{Line: 12, Column: 20}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable `settings` must not use `type = any`",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 13, Column: 10}, End: hcl.Pos{Line: 13, Column: 13}},
+		},
+		{
+			Rule:    rule,
+			Message: "variable `extra` items must appear in this order: type, description",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 17, Column: 3}, End: hcl.Pos{Line: 17, Column: 37}},
+		},
+	}, runner.Issues)
+	helper.AssertChanges(t, map[string]string{
+		"variables.tf": `variable "name" {
+  type = string
+  # what it is for
+  description = "Name of the service"
+  default     = "web"
+  validation {
+    condition     = length(var.name) > 0
+    error_message = "name must not be empty"
+  }
+}
+
+variable "settings" {
+  type = any
+}
+
+variable "extra" {
+  type        = any
+  description = "Free-form settings"
+}
+`,
+	}, runner.Changes())
+}
+
+func Test_StegraVariableStructureRule_Configured(t *testing.T) {
+	rule := NewStegraVariableStructureRule()
+	files := map[string]string{
+		"variables.tf": `variable "name" {
+  description = "Name"
+  type        = string
+}
+`,
+		".tflint.hcl": `
+rule "stegra_variable_structure" {
+  enabled  = true
+  required = ["description", "type", "nullable"]
+  order    = ["description", "type"]
+}
+`,
+	}
+	runner := helper.TestRunner(t, files)
+	if err := rule.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    rule,
+			Message: "variable `name` must set `nullable`",
+			Range:   hcl.Range{Filename: "variables.tf", Start: hcl.Pos{Line: 1, Column: 10}, End: hcl.Pos{Line: 1, Column: 16}},
+		},
+	}, runner.Issues)
+}